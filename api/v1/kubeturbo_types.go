@@ -36,6 +36,22 @@ const (
 	DefaultAnnotationVal string = "false"
 )
 
+// Condition types reported in the Kubeturbo status
+const (
+	// The operator could reach the Turbo Server through the configured proxy and trusted CAs
+	ConditionServerReachable string = "ServerReachable"
+	// The kubeturbo image version is within the supported skew of the Turbo Server version
+	ConditionVersionCompatible string = "VersionCompatible"
+	// The Kubeturbo CR clashes with an older Kubeturbo CR and is not reconciled
+	ConditionConflicted string = "Conflicted"
+	// The reconciliation of the Kubeturbo CR is paused by the kubeturbo.io/paused annotation
+	ConditionPaused string = "Paused"
+	// The kubeturbo deployed without the operator is retired and replaced by the one of the Kubeturbo CR
+	ConditionAdopted string = "Adopted"
+	// Kubeturbo is bound to the turbo-cluster-reader role by the action freeze of the Kubeturbo CR
+	ConditionActionsFrozen string = "ActionsFrozen"
+)
+
 var (
 	defaultKtVersion       = ""
 	defaultSysWlNsPatterns = []string{"kube-.*", "openshift-.*", "cattle.*"}
@@ -132,6 +148,94 @@ type KubeturboSpec struct {
 	// Specify one or more kubeturbo pod scheduling constraints in the cluster.
 	// See https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/ for examples on nodeSelector, affinity, tolerations
 	KubeturboPodScheduling KubeturboPodScheduling `json:"kubeturboPodScheduling,omitempty"`
+
+	// Liveness, readiness and startup probes of the kubeturbo container. By default the probes check kubeturbo's
+	// health endpoint and the startup probe waits as long as the registration timeout in sdkProtocolConfig
+	// +kubebuilder:default={enabled:true}
+	Probes KubeturboProbes `json:"probes,omitempty"`
+
+	// Exposes the metrics of kubeturbo through a Service and, if the Prometheus operator is installed, a ServiceMonitor
+	// +optional
+	Metrics KubeturboMetrics `json:"metrics,omitempty"`
+
+	// How long the turbo.config must stay unchanged before kubeturbo restarts to pick up the changes, e.g. 2m.
+	// Changes made within the window, like the patches of a GitOps sync, are picked up by a single restart.
+	// Kubeturbo restarts right away if not set
+	// +optional
+	RestartSettleWindow *metav1.Duration `json:"restartSettleWindow,omitempty"`
+
+	// Recurring window within which kubeturbo may be restarted, to pick up turbo.config changes, or upgraded.
+	// These changes wait for the window while the other changes apply right away. No restriction if not set
+	// +optional
+	MaintenanceWindow *KubeturboMaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// Temporarily binds kubeturbo to the turbo-cluster-reader role, so it keeps discovering the cluster
+	// but can't execute actions, e.g. during change freezes. The configured role is bound again afterwards
+	// +optional
+	ActionFreeze KubeturboActionFreeze `json:"actionFreeze,omitempty"`
+
+	// Number of revisions of the rendered configuration kept for the kubeturbo.io/rollback-to annotation
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	ConfigRevisionHistoryLimit *int32 `json:"configRevisionHistoryLimit,omitempty"` // default: 5
+}
+
+type KubeturboActionFreeze struct {
+	// Freezes the actions right away, until the time set in until if any
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// When the freeze turned on by enabled is lifted, e.g. 2025-01-06T08:00:00Z
+	// +optional
+	Until *metav1.Time `json:"until,omitempty"`
+	// Cron schedule of recurring freezes, e.g. "0 18 * * 5" to freeze the actions from Friday 6pm
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// How long a scheduled freeze lasts, one hour if not set
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// IANA time zone of the schedule, e.g. Europe/Paris. UTC if not set
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type KubeturboMaintenanceWindow struct {
+	// Cron schedule of the openings of the window, e.g. "0 2 * * 6" for Saturdays at 2am
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// How long the window stays open, one hour if not set
+	// +kubebuilder:default="1h"
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// IANA time zone of the schedule, e.g. Europe/Paris. UTC if not set
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type KubeturboMetrics struct {
+	// Create the metrics Service and ServiceMonitor
+	// +kubebuilder:default=false
+	Enabled *bool `json:"enabled,omitempty"` // default: false
+	// Interval at which Prometheus scrapes kubeturbo, e.g. 30s. Prometheus' global interval if not set
+	// +kubebuilder:validation:Pattern="^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// Additional labels of the ServiceMonitor, e.g. to match the serviceMonitorSelector of Prometheus
+	// +optional
+	ServiceMonitorLabels map[string]string `json:"serviceMonitorLabels,omitempty"`
+}
+
+type KubeturboProbes struct {
+	// Enable health probes on the kubeturbo container
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"` // default: true
+	// Overrides the default liveness probe
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"` // no default
+	// Overrides the default readiness probe
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"` // no default
+	// Overrides the default startup probe, which is otherwise sized from sdkProtocolConfig.registrationTimeoutSec
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"` // no default
 }
 
 type KubeturboPodScheduling struct {
@@ -169,11 +273,52 @@ type KubeturboImage struct {
 type KubeturboServerMeta struct {
 	// Turbo Server major version
 	Version *string `json:"version,omitempty"` // no default
+	// Query the version API of the Turbo Server and use the reported version instead of version.
+	// The last reported version is kept while the Turbo Server is unreachable
+	// +kubebuilder:default=false
+	ResolveVersion *bool `json:"resolveVersion,omitempty"` // default: false
 	// URL for Turbo Server endpoint
 	// +kubebuilder:default="https://Turbo_server_URL"
 	TurboServer string `json:"turboServer,omitempty"` // default="https://Turbo_server_URL
-	// Proxy server address
+	// Proxy server address. Deprecated, use proxyConfig instead to keep the proxy credentials out of the ConfigMap
 	Proxy *string `json:"proxy,omitempty"` // no default
+	// Proxy used to connect to the Turbo Server. Takes precedence over proxy
+	ProxyConfig KubeturboProxyConfig `json:"proxyConfig,omitempty"`
+	// CA bundle kubeturbo trusts in addition to the system CAs when connecting to the Turbo Server over TLS
+	TrustedCA KubeturboTrustedCA `json:"trustedCA,omitempty"`
+}
+
+type KubeturboProxyConfig struct {
+	// Proxy server URL without credentials, e.g. http://proxyserver:proxyport
+	URL *string `json:"url,omitempty"` // no default
+	// Secret in the Kubeturbo CR namespace holding the proxy credentials under the username and password keys.
+	// The credentials are passed to kubeturbo through the HTTPS_PROXY and HTTP_PROXY environment variables
+	// instead of turbo.config, so they have to be URL safe
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"` // no default
+	// Hosts, domains, IP addresses or CIDRs that kubeturbo reaches without the proxy
+	NoProxy []string `json:"noProxy,omitempty"` // no default
+	// Export the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables to the kubeturbo container.
+	// This is always done when secretRef is specified
+	// +kubebuilder:default=false
+	InjectEnv *bool `json:"injectEnv,omitempty"` // default: false
+	// Add the API server host, the service, pod and node networks and the in-cluster domains of the cluster to NO_PROXY
+	// +kubebuilder:default=true
+	AutoNoProxy *bool `json:"autoNoProxy,omitempty"` // default: true
+}
+
+// Only one source of the CA bundle can be specified. Changes to the content of the bundle are only
+// picked up after kubeturbo restarts
+type KubeturboTrustedCA struct {
+	// Name of a ConfigMap in the Kubeturbo CR namespace that holds the PEM encoded CA bundle
+	ConfigMapName *string `json:"configMapName,omitempty"` // no default
+	// Name of a Secret in the Kubeturbo CR namespace that holds the PEM encoded CA bundle
+	SecretName *string `json:"secretName,omitempty"` // no default
+	// Key of the PEM encoded CA bundle in the ConfigMap or Secret
+	// +kubebuilder:default=ca-bundle.crt
+	Key string `json:"key,omitempty"` // default: ca-bundle.crt
+	// On OpenShift, let the cluster network operator inject the cluster-wide trusted CA bundle
+	// into a ConfigMap created for this Kubeturbo CR
+	InjectOpenShiftTrustedCA *bool `json:"injectOpenShiftTrustedCA,omitempty"` // no default
 }
 
 type KubeturboRestAPIConfig struct {
@@ -204,6 +349,15 @@ type KubeturboHANodeConfig struct {
 type KubeturboTargetConfig struct {
 	TargetName *string `json:"targetName,omitempty"` // no default
 	// TargetType *string `json:"targetType,omitempty"` // no default
+
+	// Derive a stable target name from the UID of the kube-system namespace when targetName is not set,
+	// so that clusters don't collide in the Turbo Server
+	// +kubebuilder:default=false
+	DeriveTargetName *bool `json:"deriveTargetName,omitempty"` // default: false
+	// Prefix of the derived target name
+	// +kubebuilder:default=cluster
+	// +kubebuilder:validation:Pattern="^[a-zA-Z0-9][-_.a-zA-Z0-9]*$"
+	TargetNamePrefix *string `json:"targetNamePrefix,omitempty"` // default: cluster
 }
 
 type KubeturboArgs struct {
@@ -340,6 +494,36 @@ type KubeturboStatus struct {
 	LastUpdatedTimestamp string `json:"lastUpdatedTimestamp,omitempty"`
 	// Hash of the constructed turbo.config file
 	ConfigHash string `json:"configHash,omitempty"`
+	// Version reported by the Turbo Server at the last successful connectivity check
+	ServerVersion string `json:"serverVersion,omitempty"`
+	// Turbo Server that reported serverVersion
+	ServerURL string `json:"serverURL,omitempty"`
+	// Latest observations of the Kubeturbo state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Restart of kubeturbo waiting for the changes of the turbo.config to settle
+	// +optional
+	PendingRestart *KubeturboPendingRestart `json:"pendingRestart,omitempty"`
+	// Disruptive changes waiting for the maintenance window, e.g. an upgrade of the kubeturbo image
+	// +optional
+	PendingChanges []string `json:"pendingChanges,omitempty"`
+	// When the maintenance window opens next, set while changes are pending
+	// +optional
+	NextMaintenanceWindow *metav1.Time `json:"nextMaintenanceWindow,omitempty"`
+	// Revision of the configuration kubeturbo runs with, the hash of its turbo.config and turbo-autoreload.config
+	// +optional
+	ConfigRevision string `json:"configRevision,omitempty"`
+}
+
+type KubeturboPendingRestart struct {
+	// Hash of the turbo.config kubeturbo restarts with
+	ConfigHash string `json:"configHash"`
+	// When the first of the pending changes was seen
+	Since metav1.Time `json:"since"`
+	// When kubeturbo restarts unless the turbo.config changes again
+	RestartAfter metav1.Time `json:"restartAfter"`
 }
 
 //+kubebuilder:object:root=true
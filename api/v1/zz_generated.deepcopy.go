@@ -23,6 +23,7 @@ package v1
 import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -137,7 +138,7 @@ func (in *Kubeturbo) DeepCopyInto(out *Kubeturbo) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kubeturbo.
@@ -158,6 +159,31 @@ func (in *Kubeturbo) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboActionFreeze) DeepCopyInto(out *KubeturboActionFreeze) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Until != nil {
+		in, out := &in.Until, &out.Until
+		*out = (*in).DeepCopy()
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboActionFreeze.
+func (in *KubeturboActionFreeze) DeepCopy() *KubeturboActionFreeze {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboActionFreeze)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeturboArgs) DeepCopyInto(out *KubeturboArgs) {
 	*out = *in
@@ -375,6 +401,66 @@ func (in *KubeturboList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboMaintenanceWindow) DeepCopyInto(out *KubeturboMaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboMaintenanceWindow.
+func (in *KubeturboMaintenanceWindow) DeepCopy() *KubeturboMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboMetrics) DeepCopyInto(out *KubeturboMetrics) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ServiceMonitorLabels != nil {
+		in, out := &in.ServiceMonitorLabels, &out.ServiceMonitorLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboMetrics.
+func (in *KubeturboMetrics) DeepCopy() *KubeturboMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboPendingRestart) DeepCopyInto(out *KubeturboPendingRestart) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	in.RestartAfter.DeepCopyInto(&out.RestartAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboPendingRestart.
+func (in *KubeturboPendingRestart) DeepCopy() *KubeturboPendingRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboPendingRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeturboPodScheduling) DeepCopyInto(out *KubeturboPodScheduling) {
 	*out = *in
@@ -409,6 +495,81 @@ func (in *KubeturboPodScheduling) DeepCopy() *KubeturboPodScheduling {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboProbes) DeepCopyInto(out *KubeturboProbes) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboProbes.
+func (in *KubeturboProbes) DeepCopy() *KubeturboProbes {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboProbes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboProxyConfig) DeepCopyInto(out *KubeturboProxyConfig) {
+	*out = *in
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InjectEnv != nil {
+		in, out := &in.InjectEnv, &out.InjectEnv
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoNoProxy != nil {
+		in, out := &in.AutoNoProxy, &out.AutoNoProxy
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboProxyConfig.
+func (in *KubeturboProxyConfig) DeepCopy() *KubeturboProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeturboRestAPIConfig) DeepCopyInto(out *KubeturboRestAPIConfig) {
 	*out = *in
@@ -467,11 +628,18 @@ func (in *KubeturboServerMeta) DeepCopyInto(out *KubeturboServerMeta) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ResolveVersion != nil {
+		in, out := &in.ResolveVersion, &out.ResolveVersion
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Proxy != nil {
 		in, out := &in.Proxy, &out.Proxy
 		*out = new(string)
 		**out = **in
 	}
+	in.ProxyConfig.DeepCopyInto(&out.ProxyConfig)
+	in.TrustedCA.DeepCopyInto(&out.TrustedCA)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboServerMeta.
@@ -528,6 +696,24 @@ func (in *KubeturboSpec) DeepCopyInto(out *KubeturboSpec) {
 	in.Wiremock.DeepCopyInto(&out.Wiremock)
 	in.Discovery.DeepCopyInto(&out.Discovery)
 	in.KubeturboPodScheduling.DeepCopyInto(&out.KubeturboPodScheduling)
+	in.Probes.DeepCopyInto(&out.Probes)
+	in.Metrics.DeepCopyInto(&out.Metrics)
+	if in.RestartSettleWindow != nil {
+		in, out := &in.RestartSettleWindow, &out.RestartSettleWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(KubeturboMaintenanceWindow)
+		**out = **in
+	}
+	in.ActionFreeze.DeepCopyInto(&out.ActionFreeze)
+	if in.ConfigRevisionHistoryLimit != nil {
+		in, out := &in.ConfigRevisionHistoryLimit, &out.ConfigRevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboSpec.
@@ -543,6 +729,27 @@ func (in *KubeturboSpec) DeepCopy() *KubeturboSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeturboStatus) DeepCopyInto(out *KubeturboStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = new(KubeturboPendingRestart)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingChanges != nil {
+		in, out := &in.PendingChanges, &out.PendingChanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NextMaintenanceWindow != nil {
+		in, out := &in.NextMaintenanceWindow, &out.NextMaintenanceWindow
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboStatus.
@@ -563,6 +770,16 @@ func (in *KubeturboTargetConfig) DeepCopyInto(out *KubeturboTargetConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.DeriveTargetName != nil {
+		in, out := &in.DeriveTargetName, &out.DeriveTargetName
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TargetNamePrefix != nil {
+		in, out := &in.TargetNamePrefix, &out.TargetNamePrefix
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboTargetConfig.
@@ -575,6 +792,36 @@ func (in *KubeturboTargetConfig) DeepCopy() *KubeturboTargetConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeturboTrustedCA) DeepCopyInto(out *KubeturboTrustedCA) {
+	*out = *in
+	if in.ConfigMapName != nil {
+		in, out := &in.ConfigMapName, &out.ConfigMapName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecretName != nil {
+		in, out := &in.SecretName, &out.SecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.InjectOpenShiftTrustedCA != nil {
+		in, out := &in.InjectOpenShiftTrustedCA, &out.InjectOpenShiftTrustedCA
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeturboTrustedCA.
+func (in *KubeturboTrustedCA) DeepCopy() *KubeturboTrustedCA {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeturboTrustedCA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Logging) DeepCopyInto(out *Logging) {
 	*out = *in
@@ -0,0 +1,71 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// clusterpermissions writes the rules of the operator ClusterRoles into the clusterPermissions of the OLM ClusterServiceVersion
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/bundle"
+)
+
+func main() {
+	csvPath := flag.String("csv", "", "The ClusterServiceVersion to update in place")
+	serviceAccountName := flag.String("service-account", bundle.OperatorServiceAccount, "The service account granted the cluster permissions")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -csv <clusterserviceversion.yaml> <clusterrole.yaml>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *csvPath == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*csvPath, *serviceAccountName, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("cluster permissions role inserted successfully.")
+}
+
+func run(csvPath, serviceAccountName string, clusterRolePaths []string) error {
+	csv, err := os.ReadFile(csvPath)
+	if err != nil {
+		return err
+	}
+	clusterRoles := make([][]byte, 0, len(clusterRolePaths))
+	for _, path := range clusterRolePaths {
+		clusterRole, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		clusterRoles = append(clusterRoles, clusterRole)
+	}
+
+	updated, err := bundle.SetClusterPermissions(csv, serviceAccountName, clusterRoles...)
+	if err != nil {
+		return fmt.Errorf("unable to update %s: %w", csvPath, err)
+	}
+	info, err := os.Stat(csvPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(csvPath, updated, info.Mode())
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// helm2cr converts the values of a kubeturbo helm release into an equivalent Kubeturbo CR
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/migration"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+type options struct {
+	valuesFile     string
+	releaseSecret  string
+	name           string
+	namespace      string
+	chartDir       string
+	verify         bool
+	strict         bool
+	defaultVersion string
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.valuesFile, "values", "", "A values file passed to the chart with -f")
+	flag.StringVar(&opts.releaseSecret, "release-secret", "",
+		"The Secret of a helm release, e.g. kubectl get secret sh.helm.release.v1.kubeturbo.v1 -o yaml")
+	flag.StringVar(&opts.name, "name", "", "Name of the CR, the release name by default")
+	flag.StringVar(&opts.namespace, "namespace", "", "Namespace of the CR, the release namespace by default")
+	flag.StringVar(&opts.chartDir, "chart", "deploy/kubeturbo", "The kubeturbo helm chart used to verify the conversion")
+	flag.BoolVar(&opts.verify, "verify", true, "Compare what the chart and the operator deploy")
+	flag.BoolVar(&opts.strict, "strict", false, "Fail if a value has no equivalent or the deployed resources differ")
+	flag.StringVar(&opts.defaultVersion, "default-version", os.Getenv(utils.DefaultKubeturboVersionEnvVar),
+		"The kubeturbo version used when the CR doesn't pin one, like DEFAULT_KUBETURBO_VERSION of the operator")
+	flag.Parse()
+
+	if (opts.valuesFile == "") == (opts.releaseSecret == "") {
+		fmt.Fprintln(os.Stderr, "exactly one of -values and -release-secret is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// the builders log what they would change in a cluster, which is noise here
+	ctrl.SetLogger(logr.Discard())
+
+	equivalent, err := run(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !equivalent && opts.strict {
+		os.Exit(1)
+	}
+}
+
+func run(opts options) (bool, error) {
+	release, values, err := readValues(opts)
+	if err != nil {
+		return false, err
+	}
+
+	conversion, err := migration.FromHelmValues(values, release)
+	if err != nil {
+		return false, err
+	}
+	out, err := yaml.Marshal(conversion.Kubeturbo.Object)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		return false, err
+	}
+
+	for _, path := range conversion.Unmapped {
+		fmt.Fprintf(os.Stderr, "Warning: %s has no equivalent in the Kubeturbo CR and is dropped\n", path)
+	}
+	equivalent := len(conversion.Unmapped) == 0
+	if !opts.verify {
+		return equivalent, nil
+	}
+
+	if opts.defaultVersion != "" {
+		if err := os.Setenv(utils.DefaultKubeturboVersionEnvVar, opts.defaultVersion); err != nil {
+			return false, err
+		}
+	}
+	chart, err := helm.LoadChart(os.DirFS(opts.chartDir))
+	if err != nil {
+		return false, fmt.Errorf("unable to load the chart: %w", err)
+	}
+	differences, err := migration.Verify(chart, values, release, conversion)
+	if err != nil {
+		return false, err
+	}
+	for _, difference := range differences {
+		fmt.Fprintf(os.Stderr, "Difference: %s\n", difference)
+	}
+	if len(differences) == 0 {
+		fmt.Fprintln(os.Stderr, "The operator deploys kubeturbo the same way as the chart")
+	}
+	// the verification is only as good as the rendering of the chart
+	fmt.Fprintln(os.Stderr, "Note: the chart is rendered without the helm engine, so:")
+	for _, limitation := range helm.Limitations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", limitation)
+	}
+	return equivalent && len(differences) == 0, nil
+}
+
+func readValues(opts options) (helm.Release, map[string]interface{}, error) {
+	release := helm.Release{}
+	values := map[string]interface{}{}
+	if opts.releaseSecret != "" {
+		manifest, err := os.ReadFile(opts.releaseSecret)
+		if err != nil {
+			return release, nil, err
+		}
+		secret := &corev1.Secret{}
+		if err := yaml.Unmarshal(manifest, secret); err != nil {
+			return release, nil, fmt.Errorf("invalid Secret: %w", err)
+		}
+		if release, values, err = helm.ReleaseFromSecret(secret); err != nil {
+			return release, nil, err
+		}
+	} else {
+		manifest, err := os.ReadFile(opts.valuesFile)
+		if err != nil {
+			return release, nil, err
+		}
+		if err := yaml.Unmarshal(manifest, &values); err != nil {
+			return release, nil, fmt.Errorf("invalid values: %w", err)
+		}
+	}
+
+	if opts.name != "" {
+		release.Name = opts.name
+	}
+	if opts.namespace != "" {
+		release.Namespace = opts.namespace
+	}
+	if release.Name == "" || release.Namespace == "" {
+		return release, nil, fmt.Errorf("-name and -namespace are required for a values file")
+	}
+	return release, values, nil
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -34,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -41,7 +45,10 @@ import (
 	chartsv1alpha1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1alpha1"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/controller"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/health"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/metrics"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/runnable"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/tracing"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	//+kubebuilder:scaffold:imports
 )
@@ -68,6 +75,9 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var installCRD bool
+	var stuckReconcileThreshold time.Duration
+	var otlpTracesEndpoint string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -77,6 +87,14 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&installCRD, "install-crd", false,
+		"If set, the operator applies its Kubeturbo CRD when the installed one is missing or outdated. "+
+			"Requires the create and patch permissions on customresourcedefinitions")
+	flag.DurationVar(&stuckReconcileThreshold, "stuck-reconcile-threshold", 10*time.Minute,
+		"The operator reports unhealthy when a reconcile runs longer than that")
+	flag.StringVar(&otlpTracesEndpoint, "otlp-traces-endpoint", tracing.EndpointFromEnv(),
+		"The OTLP/HTTP endpoint the traces of the reconciles are exported to, e.g. http://otel-collector:4318/v1/traces. "+
+			"Defaults to the OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_ENDPOINT variables, no traces if empty")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -85,6 +103,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	shutdownTracing := tracing.Setup(otlpTracesEndpoint, constants.OperatorName)
+	if otlpTracesEndpoint != "" {
+		setupLog.Info("exporting traces", "endpoint", otlpTracesEndpoint)
+	}
+
 	watchNamespace, err := getWatchNamespace()
 	if err != nil {
 		setupLog.Error(err, "unable to get WATCH_NAMESPACE, "+
@@ -107,8 +130,12 @@ func main() {
 		tlsOpts = append(tlsOpts, disableHTTP2)
 	}
 
+	webhookCertDir := filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+	webhookCertName := "tls.crt"
 	webhookServer := webhook.NewServer(webhook.Options{
-		TLSOpts: tlsOpts,
+		CertDir:  webhookCertDir,
+		CertName: webhookCertName,
+		TLSOpts:  tlsOpts,
 	})
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -145,39 +172,62 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Detects a stuck reconcile for the liveness check
+	watchdog := &health.ReconcileWatchdog{Threshold: stuckReconcileThreshold}
+
 	// Channel to signal post-check completions
 	postCheckDone := make(chan interface{})
 	if err = (&controller.KubeturboReconciler{
 		Client:        mgr.GetClient(),
+		APIReader:     mgr.GetAPIReader(),
 		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor(constants.OperatorName),
 		PostCheckDone: &postCheckDone,
+		Watchdog:      watchdog,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Kubeturbo")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
 
+	// Count the Kubeturbo CRs by condition at every scrape
+	crmetrics.Registry.MustRegister(&metrics.KubeturboCollector{Reader: mgr.GetClient(), Timeout: 5 * time.Second})
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		setupLog.Error(err, "unable to set up ready check")
+	if err := mgr.AddHealthzCheck("reconcile", watchdog.Checker); err != nil {
+		setupLog.Error(err, "unable to set up reconcile check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("cache", health.CacheSynced(mgr.GetCache(), 500*time.Millisecond)); err != nil {
+		setupLog.Error(err, "unable to set up cache check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("webhook-cert", health.WebhookCertificate(webhookCertDir, webhookCertName)); err != nil {
+		setupLog.Error(err, "unable to set up webhook certificate check")
 		os.Exit(1)
 	}
 
 	// Channel to signal crd check completion
 	crdCheckDone := make(chan interface{})
 	// Register the custom Runnable for post start CRD checks
-	if err := mgr.Add(&runnable.CRDCheck{
+	crdCheck := &runnable.CRDCheck{
 		CRDName:      constants.KubeturboCRDName,
 		Client:       mgr.GetClient(),
 		Recorder:     mgr.GetEventRecorderFor("kubeturbo-operator"),
 		CRDCheckDone: &crdCheckDone,
-	}); err != nil {
+		InstallCRD:   installCRD,
+	}
+	if err := mgr.Add(crdCheck); err != nil {
 		fmt.Printf("Error adding custom Runnable: %v\n", err)
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("crd", crdCheck.Checker); err != nil {
+		setupLog.Error(err, "unable to set up crd check")
+		os.Exit(1)
+	}
 
 	go func() {
 		setupLog.Info("Waiting for post-check runners to complete...")
@@ -190,7 +240,16 @@ func main() {
 	}()
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+
+	// flush the spans of the last reconciles
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to flush the traces")
+	}
+
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// render prints the resources the operator creates for a Kubeturbo CR, without a cluster
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/render"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+func main() {
+	var defaultVersion string
+	var clusterUID string
+	flag.StringVar(&defaultVersion, "default-version", os.Getenv(utils.DefaultKubeturboVersionEnvVar),
+		"The kubeturbo version used when the CR doesn't pin one, like DEFAULT_KUBETURBO_VERSION of the operator")
+	flag.StringVar(&clusterUID, "cluster-uid", render.PlaceholderClusterUID,
+		"The UID of the kube-system namespace, used to derive the target name")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <kubeturbo-cr.yaml|->\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// the builders log what they would change in a cluster, which is noise here
+	ctrl.SetLogger(logr.Discard())
+
+	if err := run(flag.Arg(0), defaultVersion, types.UID(clusterUID)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path, defaultVersion string, clusterUID types.UID) error {
+	manifest, err := readManifest(path)
+	if err != nil {
+		return err
+	}
+	if defaultVersion != "" {
+		if err := os.Setenv(utils.DefaultKubeturboVersionEnvVar, defaultVersion); err != nil {
+			return err
+		}
+	}
+
+	kt, err := render.Decode(manifest)
+	if err != nil {
+		return err
+	}
+	objs, err := render.Objects(context.Background(), kt, clusterUID)
+	if err != nil {
+		return err
+	}
+	out, err := render.YAML(objs)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+func readManifest(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
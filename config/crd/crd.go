@@ -0,0 +1,8 @@
+// Package crd embeds the generated CustomResourceDefinitions, so that tools can
+// work with the schema the operator ships without access to a cluster
+package crd
+
+import _ "embed"
+
+//go:embed bases/charts.helm.k8s.io_kubeturbos.yaml
+var Kubeturbo []byte
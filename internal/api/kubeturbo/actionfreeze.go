@@ -0,0 +1,101 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+)
+
+const (
+	reasonFrozenManually   = "FrozenManually"
+	reasonFrozenBySchedule = "FrozenBySchedule"
+	reasonNotFrozen        = "NotFrozen"
+)
+
+// The state of the action freeze at a given time
+type actionFreezeState struct {
+	frozen bool
+	reason string
+	// when the freeze ends, zero if it lasts until turned off
+	until time.Time
+	// when the freeze may start or end next, zero if never
+	next time.Time
+}
+
+// Evaluates the action freeze of the CR at the given time. A freeze turned on manually
+// wins over the schedule until it's lifted
+func (kt *kubeturbo) actionFreeze(now time.Time) (actionFreezeState, error) {
+	freeze := kt.spec.ActionFreeze
+	state := actionFreezeState{reason: reasonNotFrozen}
+	changesAt := func(t time.Time) {
+		if t.After(now) && (state.next.IsZero() || t.Before(state.next)) {
+			state.next = t
+		}
+	}
+
+	if freeze.Schedule != "" {
+		duration := freeze.Duration.Duration
+		if duration <= 0 {
+			duration = defaultWindowDuration
+		}
+		open, opening, err := cronWindow(freeze.Schedule, freeze.TimeZone, duration, now)
+		if err != nil {
+			return state, fmt.Errorf("invalid actionFreeze: %w", err)
+		}
+		if open {
+			state.frozen, state.reason, state.until = true, reasonFrozenBySchedule, opening.Add(duration)
+			changesAt(state.until)
+		} else {
+			changesAt(opening)
+		}
+	}
+
+	if freeze.Enabled != nil && *freeze.Enabled && (freeze.Until == nil || now.Before(freeze.Until.Time)) {
+		state.frozen, state.reason, state.until = true, reasonFrozenManually, time.Time{}
+		if freeze.Until != nil {
+			state.until = freeze.Until.Time
+			changesAt(state.until)
+		}
+	}
+	return state, nil
+}
+
+// The role kubeturbo is bound to, turbo-cluster-reader while the actions are frozen
+func (kt *kubeturbo) roleName() string {
+	if kt.actionsFrozen {
+		return kubeturbosv1.RoleTypeReadOnly
+	}
+	return kt.spec.RoleName
+}
+
+// Binds kubeturbo to the reader role while the actions are frozen, see clusterRoleName and
+// mutateClusterRoleBinding, and reports the freeze in the CR status. The CR is reconciled again when
+// the freeze starts or ends
+func (kt *kubeturbo) updateActionFreeze() error {
+	state, err := kt.actionFreeze(time.Now())
+	if err != nil {
+		return err
+	}
+	kt.actionsFrozen = state.frozen
+	if !state.next.IsZero() {
+		kt.requeueAt(state.next, "the action freeze starts or ends")
+	}
+
+	if !state.frozen {
+		// no need to report on the CRs never frozen
+		if meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionActionsFrozen) == nil {
+			return nil
+		}
+		return kt.setCondition(kubeturbosv1.ConditionActionsFrozen, metav1.ConditionFalse, reasonNotFrozen,
+			fmt.Sprintf("Kubeturbo is bound to the %s role", kt.roleName()))
+	}
+	message := fmt.Sprintf("Kubeturbo is bound to the %s role and can't execute actions", kt.roleName())
+	if !state.until.IsZero() {
+		message += fmt.Sprintf(" until %s", state.until.UTC().Format(time.RFC3339))
+	}
+	return kt.setCondition(kubeturbosv1.ConditionActionsFrozen, metav1.ConditionTrue, state.reason, message)
+}
@@ -0,0 +1,108 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Action freeze", func() {
+	var kt *kubeturbo
+
+	spec := newTestSpec()
+	spec.RoleName = kubeturbosv1.RoleTypeAdmin
+	spec.OrmOwners = kubeturbosv1.OrmOwners{ApiGroup: []string{"redis.redis.opstreelabs.in"}, Resources: []string{"redis"}}
+
+	BeforeEach(func() {
+		kt = newTestKubeturbo(spec)
+		Expect(kt.Get(kt.Cr)).To(Succeed())
+	})
+
+	Describe("actionFreeze", func() {
+		// a Friday
+		now := time.Date(2024, time.June, 7, 19, 0, 0, 0, time.UTC)
+
+		It("isn't frozen by default", func() {
+			state, err := kt.actionFreeze(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(actionFreezeState{reason: reasonNotFrozen}))
+		})
+
+		It("is frozen manually until lifted", func() {
+			until := now.Add(time.Hour)
+			kt.spec.ActionFreeze = kubeturbosv1.KubeturboActionFreeze{Enabled: utils.AsPtr(true), Until: &metav1.Time{Time: until}}
+			state, err := kt.actionFreeze(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(actionFreezeState{frozen: true, reason: reasonFrozenManually, until: until, next: until}))
+
+			state, err = kt.actionFreeze(until)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.frozen).To(BeFalse())
+		})
+
+		It("is frozen within the scheduled window", func() {
+			kt.spec.ActionFreeze = kubeturbosv1.KubeturboActionFreeze{Schedule: "0 18 * * 5", Duration: metav1.Duration{Duration: 62 * time.Hour}}
+			state, err := kt.actionFreeze(now)
+			Expect(err).NotTo(HaveOccurred())
+			monday := time.Date(2024, time.June, 10, 8, 0, 0, 0, time.UTC)
+			Expect(state).To(Equal(actionFreezeState{frozen: true, reason: reasonFrozenBySchedule, until: monday, next: monday}))
+
+			state, err = kt.actionFreeze(monday)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(actionFreezeState{reason: reasonNotFrozen, next: time.Date(2024, time.June, 14, 18, 0, 0, 0, time.UTC)}))
+		})
+
+		It("rejects an invalid schedule", func() {
+			kt.spec.ActionFreeze = kubeturbosv1.KubeturboActionFreeze{Schedule: "fridays"}
+			_, err := kt.actionFreeze(now)
+			Expect(err).To(MatchError(ContainSubstring("invalid actionFreeze")))
+		})
+	})
+
+	bindAndGetRole := func() string {
+		Expect(kt.updateActionFreeze()).To(Succeed())
+		Expect(kt.reconcileStep("createOrUpdateClusterRole")).To(Succeed())
+		// the role of a binding can't change, it's deleted first
+		if err := kt.reconcileStep("createOrUpdateClusterRoleBinding"); err != nil {
+			Expect(err).To(MatchError(constants.ErrRequeueOnDeletion))
+			Expect(kt.reconcileStep("createOrUpdateClusterRoleBinding")).To(Succeed())
+		}
+		crb := kt.clusterRoleBinding()
+		Expect(kt.Get(crb)).To(Succeed())
+		return crb.RoleRef.Name
+	}
+
+	It("binds the reader role while frozen and the configured role afterwards", func() {
+		adminRole := "turbo-cluster-admin-kubeturbo-release-turbo"
+		readerRole := "turbo-cluster-reader-kubeturbo-release-turbo"
+		Expect(bindAndGetRole()).To(Equal(adminRole))
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionActionsFrozen)).To(BeNil())
+
+		until := time.Now().Add(time.Hour)
+		kt.spec.ActionFreeze = kubeturbosv1.KubeturboActionFreeze{Enabled: utils.AsPtr(true), Until: &metav1.Time{Time: until}}
+		Expect(bindAndGetRole()).To(Equal(readerRole))
+		reader := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: readerRole}}
+		Expect(kt.Get(reader)).To(Succeed())
+		for _, rule := range reader.Rules {
+			Expect(rule.Verbs).NotTo(ContainElements("update", "patch", "*"))
+		}
+		condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionActionsFrozen)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(reasonFrozenManually))
+		Expect(kt.awaitSchedule()).To(BeAssignableToTypeOf(&constants.RequeueAfterError{}))
+
+		// the freeze got lifted
+		kt.spec.ActionFreeze.Until = &metav1.Time{Time: time.Now().Add(-time.Second)}
+		Expect(bindAndGetRole()).To(Equal(adminRole))
+		Expect(meta.IsStatusConditionFalse(kt.Cr.Status.Conditions, kubeturbosv1.ConditionActionsFrozen)).To(BeTrue())
+		Expect(kt.Get(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: adminRole}})).To(Succeed())
+	})
+})
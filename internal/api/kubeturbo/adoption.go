@@ -0,0 +1,691 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+// Reasons of the Adopted condition
+const (
+	reasonNothingToAdopt = "NothingToAdopt"
+	reasonAmbiguousAdopt = "AmbiguousAdoption"
+	reasonNotAdoptable   = "NotAdoptable"
+	reasonRetiringLegacy = "RetiringLegacyKubeturbo"
+	reasonAdopted        = "Adopted"
+)
+
+const (
+	turboConfigFlag    = "--turboconfig"
+	credentialsPath    = "/etc/turbonomic-credentials"
+	turboConfigKey     = "turbo.config"
+	dynamicConfigKey   = "turbo-autoreload.config"
+	helmManagedBy      = "Helm"
+	helmReleaseOwner   = "helm"
+	adoptByDiscovering = "true"
+	// set by helm on the objects of a release
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	// the defaults of the chart, which the YAML manifests are rendered with
+	legacyRelease     = "kubeturbo"
+	legacyRoleBinding = "turbo-all-binding"
+)
+
+// Takes over a kubeturbo deployed with the helm chart or the YAML manifests. The spec of the CR is
+// inferred from the legacy Deployment and ConfigMap, then the legacy kubeturbo is scaled down and
+// removed before the operator creates its own Deployment, so that the cluster is never registered twice.
+// A CR blocked by a conflict with another CR leaves the legacy kubeturbo alone
+func (kt *kubeturbo) adoptLegacyKubeturbo() error {
+	target, requested := kt.Cr.GetAnnotations()[constants.AdoptAnnotation]
+	if !requested || meta.IsStatusConditionTrue(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted) {
+		return nil
+	}
+
+	dep, err := kt.findLegacyDeployment(target)
+	if err != nil {
+		return err
+	}
+
+	existing := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted)
+	retiring := existing != nil && existing.Reason == reasonRetiringLegacy
+	if dep == nil {
+		if retiring {
+			// the legacy Deployment went away after the spec was inferred
+			return kt.setCondition(kubeturbosv1.ConditionAdopted, metav1.ConditionTrue, reasonAdopted, existing.Message)
+		}
+		return kt.setCondition(kubeturbosv1.ConditionAdopted, metav1.ConditionFalse, reasonNothingToAdopt,
+			fmt.Sprintf("No kubeturbo deployed without the operator found in namespace %s", kt.Namespace()))
+	}
+
+	if !retiring {
+		if err := kt.inferSpecFrom(dep); err != nil {
+			return err
+		}
+		// the inferred spec may clash with another CR, the legacy kubeturbo keeps running then
+		if err := utils.ReturnOnError(kt.resolveTargetName, kt.checkConflicts); err != nil {
+			return err
+		}
+		if err := kt.setCondition(kubeturbosv1.ConditionAdopted, metav1.ConditionFalse, reasonRetiringLegacy,
+			fmt.Sprintf("The spec is inferred from Deployment %s, which is being retired", dep.Name)); err != nil {
+			return err
+		}
+	}
+
+	if err := kt.retireLegacyKubeturbo(dep); err != nil {
+		return err
+	}
+	return kt.setCondition(kubeturbosv1.ConditionAdopted, metav1.ConditionTrue, reasonAdopted,
+		fmt.Sprintf("Took over the kubeturbo of Deployment %s", dep.Name))
+}
+
+// Returns the Deployment named in the annotation, or the only kubeturbo Deployment of the namespace
+// not managed by a Kubeturbo CR. Returns nil if there is none
+func (kt *kubeturbo) findLegacyDeployment(target string) (*appsv1.Deployment, error) {
+	if target != "" && target != adoptByDiscovering {
+		dep := &appsv1.Deployment{}
+		if err := kt.APIReader.Get(kt.Context, types.NamespacedName{Name: target, Namespace: kt.Namespace()}, dep); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if isLegacyKubeturbo(dep) {
+			return dep, nil
+		}
+		// the Deployment of this CR once adopted, or one that isn't kubeturbo at all
+		if owner := metav1.GetControllerOf(dep); owner != nil && owner.UID == kt.Cr.UID {
+			return nil, nil
+		}
+		return nil, kt.blockReconcile(kubeturbosv1.ConditionAdopted, metav1.ConditionFalse, reasonNotAdoptable,
+			fmt.Sprintf("Deployment %s is not a kubeturbo deployed without the operator", target))
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := kt.APIReader.List(kt.Context, deployments, client.InNamespace(kt.Namespace())); err != nil {
+		return nil, err
+	}
+	var candidates []string
+	var found *appsv1.Deployment
+	for i := range deployments.Items {
+		if isLegacyKubeturbo(&deployments.Items[i]) {
+			found = &deployments.Items[i]
+			candidates = append(candidates, found.Name)
+		}
+	}
+	if len(candidates) > 1 {
+		return nil, kt.blockReconcile(kubeturbosv1.ConditionAdopted, metav1.ConditionFalse, reasonAmbiguousAdopt,
+			fmt.Sprintf("Several kubeturbo Deployments can be adopted: %s. Set the %s annotation to the one to adopt",
+				strings.Join(candidates, ", "), constants.AdoptAnnotation))
+	}
+	return found, nil
+}
+
+// A kubeturbo Deployment runs a container reading turbo.config and is not controlled by a Kubeturbo CR
+func isLegacyKubeturbo(dep *appsv1.Deployment) bool {
+	if owner := metav1.GetControllerOf(dep); owner != nil && owner.Kind == "Kubeturbo" {
+		return false
+	}
+	return legacyContainer(dep) != nil
+}
+
+func legacyContainer(dep *appsv1.Deployment) *corev1.Container {
+	containers := dep.Spec.Template.Spec.Containers
+	for i := range containers {
+		for _, arg := range containers[i].Args {
+			if strings.HasPrefix(arg, turboConfigFlag+"=") {
+				return &containers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// The ConfigMap mounted where the legacy kubeturbo reads its turbo.config from
+func legacyConfigMapName(dep *appsv1.Deployment) string {
+	container := legacyContainer(dep)
+	configDir := ""
+	for _, arg := range container.Args {
+		if value, found := strings.CutPrefix(arg, turboConfigFlag+"="); found {
+			configDir = path.Dir(value)
+		}
+	}
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath != configDir {
+			continue
+		}
+		for _, volume := range dep.Spec.Template.Spec.Volumes {
+			if volume.Name == mount.Name && volume.ConfigMap != nil {
+				return volume.ConfigMap.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (kt *kubeturbo) legacyConfigMap(dep *appsv1.Deployment) (*corev1.ConfigMap, error) {
+	name := legacyConfigMapName(dep)
+	if name == "" {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := kt.APIReader.Get(kt.Context, types.NamespacedName{Name: name, Namespace: kt.Namespace()}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cm, nil
+}
+
+// Replaces the spec of the CR with the configuration of the legacy kubeturbo
+func (kt *kubeturbo) inferSpecFrom(dep *appsv1.Deployment) error {
+	cm, err := kt.legacyConfigMap(dep)
+	if err != nil {
+		return err
+	}
+	if err := inferSpec(&kt.Cr.Spec, dep, cm); err != nil {
+		return fmt.Errorf("unable to infer the spec from Deployment %s: %w", dep.Name, err)
+	}
+	if err := kt.storeLegacyCredentials(cm); err != nil {
+		return err
+	}
+	if err := kt.Update(kt.Cr); err != nil {
+		return err
+	}
+	kt.spec = kt.Cr.Spec
+	kt.logger.Info(fmt.Sprintf("Inferred the spec of %s from Deployment %s", kt.Name(), dep.Name))
+	return nil
+}
+
+// The Turbo server credentials a legacy turbo.config holds in plain text are moved to the credentials
+// Secret rather than to the spec of the CR. Credentials already in the Secret are kept, and the Secret
+// isn't owned by the CR, like one created by the user
+func (kt *kubeturbo) storeLegacyCredentials(cm *corev1.ConfigMap) error {
+	if cm == nil {
+		return nil
+	}
+	username, password, err := inferCredentials(cm.Data[turboConfigKey])
+	if err != nil {
+		return fmt.Errorf("invalid %s in ConfigMap %s: %w", turboConfigKey, cm.Name, err)
+	}
+	if username == "" {
+		return nil
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: kt.Cr.Spec.RestAPIConfig.TurbonomicCredentialsSecretName, Namespace: kt.Namespace()}}
+	_, err = kt.CreateOrUpdate(secret, func() error {
+		if len(secret.Data[credentialsUsernameKey]) > 0 {
+			return nil
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[credentialsUsernameKey] = []byte(username)
+		secret.Data[credentialsPasswordKey] = []byte(password)
+		return nil
+	})
+	return err
+}
+
+// Scales the legacy kubeturbo down and removes it once its pods are gone. The ServiceAccount and
+// a ConfigMap of the same name are kept and owned by the CR, the Secrets of a helm release are removed
+// so that helm doesn't delete the adopted objects
+func (kt *kubeturbo) retireLegacyKubeturbo(dep *appsv1.Deployment) error {
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 0 {
+		dep.Spec.Replicas = utils.AsPtr[int32](0)
+		if err := kt.Update(dep); err != nil {
+			return err
+		}
+		kt.logger.Info(fmt.Sprintf("Scaled down the legacy kubeturbo Deployment %s", dep.Name))
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := kt.APIReader.List(kt.Context, pods, client.InNamespace(kt.Namespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return err
+	}
+	if len(pods.Items) > 0 {
+		kt.logger.Info(fmt.Sprintf("Waiting for the %d pods of Deployment %s to terminate", len(pods.Items), dep.Name))
+		return constants.ErrRequeueOnDeletion
+	}
+
+	if name := legacyConfigMapName(dep); name != "" {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kt.Namespace()}}
+		if name == kt.configMapName() {
+			if err := kt.takeOwnership(cm); err != nil {
+				return err
+			}
+		} else if err := kt.DeleteIfExists(cm); err != nil {
+			return err
+		}
+	}
+	if err := kt.takeOwnership(kt.serviceAccount()); err != nil {
+		return err
+	}
+	if err := kt.deleteLegacyClusterRoleBindings(dep); err != nil {
+		return err
+	}
+	if err := kt.deleteHelmRelease(dep); err != nil {
+		return err
+	}
+	if err := kt.DeleteIfExists(dep); err != nil {
+		return err
+	}
+	kt.logger.Info(fmt.Sprintf("Retired the legacy kubeturbo Deployment %s", dep.Name))
+	return nil
+}
+
+// Sets the CR as the controller of an existing object, which CreateOrUpdate keeps as it is
+func (kt *kubeturbo) takeOwnership(obj client.Object) error {
+	if err := kt.Get(obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if err := ctrl.SetControllerReference(kt.Cr, obj, kt.Scheme); err != nil {
+		return err
+	}
+	return kt.Update(obj)
+}
+
+// The bindings the helm chart or the YAML manifests created for the legacy ServiceAccount are replaced
+// by the ClusterRoleBinding of the CR. Bindings the user created for the ServiceAccount are kept
+func (kt *kubeturbo) deleteLegacyClusterRoleBindings(dep *appsv1.Deployment) error {
+	bindings := &rbacv1.ClusterRoleBindingList{}
+	if err := kt.APIReader.List(kt.Context, bindings); err != nil {
+		return err
+	}
+	for i := range bindings.Items {
+		crb := &bindings.Items[i]
+		if crb.Name == kt.clusterRoleBinding().Name || !kt.isLegacyClusterRoleBinding(crb, dep) {
+			continue
+		}
+		for _, subject := range crb.Subjects {
+			if subject.Kind == rbacv1.ServiceAccountKind && subject.Name == kt.serviceAccountName() && subject.Namespace == kt.Namespace() {
+				if err := kt.DeleteIfExists(crb); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// A binding installed by the helm release of the legacy Deployment, or named like the binding of the
+// chart, <roleBinding>-<release>-<namespace>, which the YAML manifests use with the release kubeturbo
+func (kt *kubeturbo) isLegacyClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, dep *appsv1.Deployment) bool {
+	releases := []string{legacyRelease}
+	if release := dep.Labels[constants.InstanceLabelKey]; dep.Labels[constants.ManagedByLabelKey] == helmManagedBy && release != "" {
+		if crb.Annotations[helmReleaseNameAnnotation] == release && crb.Annotations[helmReleaseNamespaceAnnotation] == kt.Namespace() {
+			return true
+		}
+		releases = append(releases, release)
+	}
+	for _, roleBinding := range []string{legacyRoleBinding, kt.spec.RoleBinding} {
+		for _, release := range releases {
+			if crb.Name == roleBinding+"-"+release+"-"+kt.Namespace() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (kt *kubeturbo) deleteHelmRelease(dep *appsv1.Deployment) error {
+	release := dep.Labels[constants.InstanceLabelKey]
+	if dep.Labels[constants.ManagedByLabelKey] != helmManagedBy || release == "" {
+		return nil
+	}
+	secrets := &corev1.SecretList{}
+	if err := kt.APIReader.List(kt.Context, secrets, client.InNamespace(kt.Namespace()),
+		client.MatchingLabels{"owner": helmReleaseOwner, "name": release}); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		if err := kt.DeleteIfExists(&secrets.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The parts of turbo.config the spec covers, in the layout of both the chart and the operator
+type legacyTurboConfig struct {
+	CommunicationConfig struct {
+		ServerMeta struct {
+			Version     string `json:"version"`
+			TurboServer string `json:"turboServer"`
+			Proxy       string `json:"proxy"`
+		} `json:"serverMeta"`
+		RestAPIConfig struct {
+			OpsManagerUserName string `json:"opsManagerUserName"`
+			OpsManagerPassword string `json:"opsManagerPassword"`
+		} `json:"restAPIConfig"`
+		SdkProtocolConfig struct {
+			RegistrationTimeoutSec       *int  `json:"registrationTimeoutSec"`
+			RestartOnRegistrationTimeout *bool `json:"restartOnRegistrationTimeout"`
+		} `json:"sdkProtocolConfig"`
+	} `json:"communicationConfig"`
+	FeatureGates map[string]bool `json:"featureGates"`
+	HANodeConfig struct {
+		NodeRoles []string `json:"nodeRoles"`
+		Roles     []string `json:"roles"`
+	} `json:"HANodeConfig"`
+	TargetConfig struct {
+		TargetName string `json:"targetName"`
+	} `json:"targetConfig"`
+	// the chart spells it annotationWhitelist and the operator annotationWhiteList, json matches both
+	AnnotationWhitelist kubeturbosv1.AnnotationWhitelist `json:"annotationWhitelist"`
+}
+
+type legacyDynamicConfig struct {
+	// {"level": 2} in the chart, a plain number in the operator
+	Logging                 json.RawMessage                      `json:"logging"`
+	NodePoolSize            kubeturbosv1.NodePoolSize            `json:"nodePoolSize"`
+	SystemWorkloadDetectors kubeturbosv1.SystemWorkloadDetectors `json:"systemWorkloadDetectors"`
+	ExclusionDetectors      kubeturbosv1.ExclusionDetectors      `json:"exclusionDetectors"`
+	DaemonPodDetectors      struct {
+		Namespaces      []string `json:"namespaces"`
+		PodNamePatterns []string `json:"podNamePatterns"`
+	} `json:"daemonPodDetectors"`
+	Discovery kubeturbosv1.Discovery `json:"discovery"`
+	Wiremock  kubeturbosv1.Wiremock  `json:"wiremock"`
+}
+
+// Fills the spec with the configuration of a kubeturbo deployed with the helm chart or the YAML manifests.
+// Settings the legacy objects leave out keep their value in the spec
+func inferSpec(spec *kubeturbosv1.KubeturboSpec, dep *appsv1.Deployment, cm *corev1.ConfigMap) error {
+	podSpec := dep.Spec.Template.Spec
+	container := legacyContainer(dep)
+
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas > 0 {
+		spec.ReplicaCount = dep.Spec.Replicas
+	}
+	if len(dep.Spec.Template.Annotations) > 0 {
+		spec.Annotations = dep.Spec.Template.Annotations
+	}
+	if podSpec.ServiceAccountName != "" {
+		spec.ServiceAccountName = podSpec.ServiceAccountName
+	}
+	if len(podSpec.ImagePullSecrets) > 0 {
+		spec.Image.ImagePullSecret = utils.AsPtr(podSpec.ImagePullSecrets[0].Name)
+	}
+	spec.KubeturboPodScheduling = kubeturbosv1.KubeturboPodScheduling{
+		NodeSelector: podSpec.NodeSelector,
+		Affinity:     podSpec.Affinity,
+		Tolerations:  podSpec.Tolerations,
+	}
+
+	repository, tag, err := splitImage(container.Image)
+	if err != nil {
+		return err
+	}
+	spec.Image.Repository, spec.Image.Tag = repository, &tag
+	if container.ImagePullPolicy != "" {
+		spec.Image.PullPolicy = utils.AsPtr(container.ImagePullPolicy)
+	}
+	if len(container.Resources.Limits) > 0 || len(container.Resources.Requests) > 0 {
+		spec.Resources = &kubeturbosv1.ResourceRequirements{
+			Limits:   container.Resources.Limits,
+			Requests: container.Resources.Requests,
+		}
+	}
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath != credentialsPath {
+			continue
+		}
+		for _, volume := range podSpec.Volumes {
+			if volume.Name == mount.Name && volume.Secret != nil {
+				spec.RestAPIConfig.TurbonomicCredentialsSecretName = volume.Secret.SecretName
+			}
+		}
+	}
+	if err := inferArgs(spec, container.Args); err != nil {
+		return err
+	}
+
+	if cm == nil {
+		return nil
+	}
+	if err := inferTurboConfig(spec, cm.Data[turboConfigKey]); err != nil {
+		return fmt.Errorf("invalid %s in ConfigMap %s: %w", turboConfigKey, cm.Name, err)
+	}
+	if err := inferDynamicConfig(spec, cm.Data[dynamicConfigKey]); err != nil {
+		return fmt.Errorf("invalid %s in ConfigMap %s: %w", dynamicConfigKey, cm.Name, err)
+	}
+	return nil
+}
+
+// Splits an image into repository and tag, e.g. icr.io/cpopen/turbonomic/kubeturbo:8.14.3
+func splitImage(image string) (string, string, error) {
+	if strings.Contains(image, "@") {
+		return "", "", fmt.Errorf("image %s is pinned by digest, which the Kubeturbo CR doesn't support", image)
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, "latest", nil
+	}
+	return image[:i], image[i+1:], nil
+}
+
+// The reverse of containerArgs
+func inferArgs(spec *kubeturbosv1.KubeturboSpec, args []string) error {
+	ktArgs := &spec.Args
+	for _, arg := range args {
+		flag, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch flag {
+		case "--v":
+			ktArgs.Logginglevel, err = parseArg(value, strconv.Atoi)
+		case "--kubelet-https":
+			ktArgs.Kubelethttps, err = parseArg(value, strconv.ParseBool)
+		case "--kubelet-port":
+			ktArgs.Kubeletport, err = parseArg(value, strconv.Atoi)
+		case "--scc-support":
+			ktArgs.Sccsupport = utils.AsPtr(value)
+		case "--readiness-retry-threshold":
+			ktArgs.ReadinessRetryThreshold, err = parseArg(value, func(s string) (int32, error) {
+				i, err := strconv.ParseInt(s, 10, 32)
+				return int32(i), err
+			})
+		case "--fail-volume-pod-moves":
+			ktArgs.FailVolumePodMoves, err = parseArg(value, strconv.ParseBool)
+		case "--busybox-image":
+			spec.Image.BusyboxRepository = utils.AsPtr(value)
+		case "--cpufreqgetter-image":
+			spec.Image.CpufreqgetterRepository = utils.AsPtr(value)
+		case "--cpufreq-job-exclude-node-labels":
+			ktArgs.BusyboxExcludeNodeLabels = utils.AsPtr(value)
+		case "--stitch-uuid":
+			ktArgs.Stitchuuid, err = parseArg(value, strconv.ParseBool)
+		case "--k8sVersion":
+			ktArgs.Pre16K8sVersion = utils.AsPtr(value == "1.5")
+		case "--cleanup-scc-impersonation-resources":
+			ktArgs.CleanupSccImpersonationResources, err = parseArg(value, strconv.ParseBool)
+		case "--skip-creating-scc-impersonation-resources":
+			ktArgs.SkipCreatingSccImpersonationResources, err = parseArg(value, strconv.ParseBool)
+		case "--git-email":
+			ktArgs.GitEmail = utils.AsPtr(value)
+		case "--git-username":
+			ktArgs.GitUsername = utils.AsPtr(value)
+		case "--git-secret-name":
+			ktArgs.GitSecretName = utils.AsPtr(value)
+		case "--git-secret-namespace":
+			ktArgs.GitSecretNamespace = utils.AsPtr(value)
+		case "--git-commit-mode":
+			ktArgs.GitCommitMode = utils.AsPtr(value)
+		case "--satellite-location-provider":
+			ktArgs.SatelliteLocationProvider = utils.AsPtr(value)
+		case "--discovery-interval-sec":
+			ktArgs.DiscoveryIntervalSec, err = parseArg(value, strconv.Atoi)
+		case "--discovery-sample-interval":
+			ktArgs.DiscoverySampleIntervalSec, err = parseArg(value, strconv.Atoi)
+		case "--discovery-samples":
+			ktArgs.DiscoverySamples, err = parseArg(value, strconv.Atoi)
+		case "--discovery-timeout-sec":
+			ktArgs.DiscoveryTimeoutSec, err = parseArg(value, strconv.Atoi)
+		case "--garbage-collection-interval":
+			ktArgs.GarbageCollectionIntervalMin, err = parseArg(value, strconv.Atoi)
+		case "--discovery-workers":
+			ktArgs.DiscoveryWorkers, err = parseArg(value, strconv.Atoi)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid argument %s: %w", arg, err)
+		}
+	}
+	return nil
+}
+
+func parseArg[T any](value string, parse func(string) (T, error)) (*T, error) {
+	v, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func inferTurboConfig(spec *kubeturbosv1.KubeturboSpec, data string) error {
+	if data == "" {
+		return nil
+	}
+	config := legacyTurboConfig{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return err
+	}
+
+	serverMeta := config.CommunicationConfig.ServerMeta
+	if serverMeta.TurboServer != "" {
+		spec.ServerMeta.TurboServer = serverMeta.TurboServer
+	}
+	if serverMeta.Version != "" {
+		spec.ServerMeta.Version = utils.AsPtr(serverMeta.Version)
+	}
+	if serverMeta.Proxy != "" {
+		spec.ServerMeta.Proxy = utils.AsPtr(serverMeta.Proxy)
+	}
+	// the credentials are left to inferCredentials, they don't belong in the spec
+	sdkProtocolConfig := config.CommunicationConfig.SdkProtocolConfig
+	if sdkProtocolConfig.RegistrationTimeoutSec != nil {
+		spec.SdkProtocolConfig.RegistrationTimeoutSec = sdkProtocolConfig.RegistrationTimeoutSec
+	}
+	if sdkProtocolConfig.RestartOnRegistrationTimeout != nil {
+		spec.SdkProtocolConfig.RestartOnRegistrationTimeout = sdkProtocolConfig.RestartOnRegistrationTimeout
+	}
+	if len(config.FeatureGates) > 0 {
+		spec.FeatureGates = config.FeatureGates
+	}
+
+	roles := config.HANodeConfig.NodeRoles
+	if len(roles) == 0 {
+		roles = config.HANodeConfig.Roles
+	}
+	if len(roles) > 0 {
+		quoted := make([]string, 0, len(roles))
+		for _, role := range roles {
+			quoted = append(quoted, strconv.Quote(role))
+		}
+		spec.HANodeConfig.NodeRoles = strings.Join(quoted, ",")
+	}
+
+	if config.TargetConfig.TargetName != "" {
+		spec.TargetConfig.TargetName = utils.AsPtr(config.TargetConfig.TargetName)
+	}
+	whitelist := config.AnnotationWhitelist
+	for _, pattern := range []**string{&whitelist.ContainerSpec, &whitelist.Namespace, &whitelist.WorkloadController} {
+		if *pattern != nil && **pattern == "" {
+			*pattern = nil
+		}
+	}
+	if whitelist.ContainerSpec != nil || whitelist.Namespace != nil || whitelist.WorkloadController != nil {
+		spec.AnnotationWhitelist = whitelist
+	}
+	return nil
+}
+
+// Returns the Turbo server credentials of turbo.config. The chart writes empty credentials when they
+// are kept in the credentials Secret
+func inferCredentials(data string) (string, string, error) {
+	if data == "" {
+		return "", "", nil
+	}
+	config := legacyTurboConfig{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return "", "", err
+	}
+	restAPIConfig := config.CommunicationConfig.RestAPIConfig
+	if restAPIConfig.OpsManagerUserName == "" || restAPIConfig.OpsManagerPassword == "" {
+		return "", "", nil
+	}
+	return restAPIConfig.OpsManagerUserName, restAPIConfig.OpsManagerPassword, nil
+}
+
+func inferDynamicConfig(spec *kubeturbosv1.KubeturboSpec, data string) error {
+	if data == "" {
+		return nil
+	}
+	config := legacyDynamicConfig{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return err
+	}
+
+	if len(config.Logging) > 0 {
+		logging := kubeturbosv1.Logging{}
+		if err := json.Unmarshal(config.Logging, &logging.Level); err != nil {
+			if err := json.Unmarshal(config.Logging, &logging); err != nil {
+				return err
+			}
+		}
+		if logging.Level != nil {
+			spec.Logging = logging
+		}
+	}
+	if config.NodePoolSize.Min != nil {
+		spec.NodePoolSize.Min = config.NodePoolSize.Min
+	}
+	if config.NodePoolSize.Max != nil {
+		spec.NodePoolSize.Max = config.NodePoolSize.Max
+	}
+	// the chart writes empty lists for the detectors left out
+	if len(config.SystemWorkloadDetectors.NamespacePatterns) > 0 {
+		spec.SystemWorkloadDetectors = config.SystemWorkloadDetectors
+	}
+	if len(config.ExclusionDetectors.OperatorControlledWorkloadsPatterns) > 0 {
+		spec.ExclusionDetectors.OperatorControlledWorkloadsPatterns = config.ExclusionDetectors.OperatorControlledWorkloadsPatterns
+	}
+	if len(config.ExclusionDetectors.OperatorControlledNamespacePatterns) > 0 {
+		spec.ExclusionDetectors.OperatorControlledNamespacePatterns = config.ExclusionDetectors.OperatorControlledNamespacePatterns
+	}
+	if len(config.DaemonPodDetectors.Namespaces) > 0 {
+		spec.DaemonPodDetectors.NamespacePatterns = config.DaemonPodDetectors.Namespaces
+	}
+	if len(config.DaemonPodDetectors.PodNamePatterns) > 0 {
+		spec.DaemonPodDetectors.PodNamePatterns = config.DaemonPodDetectors.PodNamePatterns
+	}
+	if config.Discovery.ChunkSendDelayMillis != nil {
+		spec.Discovery.ChunkSendDelayMillis = config.Discovery.ChunkSendDelayMillis
+	}
+	if config.Discovery.NumObjectsPerChunk != nil {
+		spec.Discovery.NumObjectsPerChunk = config.Discovery.NumObjectsPerChunk
+	}
+	if config.Wiremock.Enabled != nil && *config.Wiremock.Enabled {
+		spec.Wiremock = config.Wiremock
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package kubeturbo
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Adoption", func() {
+	// the CRD defaults of a CR created for the helm release, before the operator resolved anything
+	spec := newTestSpec()
+	spec.ServerMeta = kubeturbosv1.KubeturboServerMeta{TurboServer: "https://Turbo_server_URL"}
+	spec.RoleName = kubeturbosv1.RoleTypeAdmin
+	spec.Image = kubeturbosv1.KubeturboImage{}
+	selector := map[string]string{"app.kubernetes.io/name": "kubeturbo", "app.kubernetes.io/instance": "kubeturbo"}
+
+	// objects the kubeturbo helm chart creates for the release kubeturbo
+	helmDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kubeturbo", Namespace: "turbo",
+				Labels: map[string]string{constants.InstanceLabelKey: "kubeturbo", constants.ManagedByLabelKey: "Helm"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: utils.AsPtr[int32](1),
+				Selector: &metav1.LabelSelector{MatchLabels: selector},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: selector},
+					Spec: corev1.PodSpec{
+						ServiceAccountName: "turbo-user",
+						NodeSelector:       map[string]string{"kubernetes.io/os": "linux"},
+						Containers: []corev1.Container{{
+							Name:            "kubeturbo",
+							Image:           "icr.io/cpopen/turbonomic/kubeturbo:8.14.3",
+							ImagePullPolicy: corev1.PullAlways,
+							Args:            []string{"--turboconfig=/etc/kubeturbo/turbo.config", "--v=4", "--kubelet-https=true", "--kubelet-port=10250", "--stitch-uuid=false"},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "turbo-volume", MountPath: "/etc/kubeturbo"},
+								{Name: "turbonomic-credentials-volume", MountPath: "/etc/turbonomic-credentials"},
+							},
+						}},
+						Volumes: []corev1.Volume{
+							{Name: "turbo-volume", VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "turbo-config-kubeturbo"}},
+							}},
+							{Name: "turbonomic-credentials-volume", VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: "my-credentials"},
+							}},
+						},
+					},
+				},
+			},
+		}
+	}
+	helmConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "turbo-config-kubeturbo", Namespace: "turbo"},
+			Data: map[string]string{
+				"turbo.config": `{
+  "communicationConfig": {
+    "serverMeta": {"version": "8.14", "turboServer": "https://turbo.example.com"},
+    "restAPIConfig": {"opsManagerUserName": "", "opsManagerPassword": ""},
+    "sdkProtocolConfig": {"registrationTimeoutSec": 120, "restartOnRegistrationTimeout": true}
+  },
+  "HANodeConfig": {"nodeRoles": ["master", "worker"]},
+  "targetConfig": {"targetName": "prod"},
+  "annotationWhitelist": {"containerSpec": "", "namespace": "kubeturbo.io/.*", "workloadController": ""}
+}`,
+				"turbo-autoreload.config": `{
+  "logging": {"level": 3},
+  "nodePoolSize": {"min": 1, "max": 500},
+  "systemWorkloadDetectors": {"namespacePatterns": []},
+  "daemonPodDetectors": {"namespaces": [], "podNamePatterns": ["fluentd.*"]}
+}`,
+			},
+		}
+	}
+	legacyObjects := func() []client.Object {
+		return []client.Object{
+			helmDeployment(),
+			helmConfigMap(),
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "turbo-user", Namespace: "turbo"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kubeturbo-abc", Namespace: "turbo", Labels: selector}},
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "turbo-all-binding-kubeturbo-turbo"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "turbo-user", Namespace: "turbo"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+			// installed by helm with a custom roleBinding value
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "kubeturbo-reader-kubeturbo-turbo", Annotations: map[string]string{
+					"meta.helm.sh/release-name": "kubeturbo", "meta.helm.sh/release-namespace": "turbo",
+				}},
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "turbo-user", Namespace: "turbo"}},
+				RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: "turbo-cluster-reader"},
+			},
+			// created by the user
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "turbo-user-view"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "turbo-user", Namespace: "turbo"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "sh.helm.release.v1.kubeturbo.v1", Namespace: "turbo",
+				Labels: map[string]string{"owner": "helm", "name": "kubeturbo"},
+			}},
+		}
+	}
+	adopting := func(kt *kubeturbo, target string) *kubeturbo {
+		kt.Cr.Annotations = map[string]string{constants.AdoptAnnotation: target}
+		return kt
+	}
+	exists := func(kt *kubeturbo, obj client.Object) bool {
+		err := kt.Get(obj)
+		Expect(client.IgnoreNotFound(err)).To(Succeed())
+		return !errors.IsNotFound(err)
+	}
+
+	It("Infers the spec from the objects of the helm chart", func() {
+		ktSpec := *spec.DeepCopy()
+		Expect(inferSpec(&ktSpec, helmDeployment(), helmConfigMap())).To(Succeed())
+
+		Expect(ktSpec.Image.Repository).To(Equal("icr.io/cpopen/turbonomic/kubeturbo"))
+		Expect(*ktSpec.Image.Tag).To(Equal("8.14.3"))
+		Expect(*ktSpec.Image.PullPolicy).To(Equal(corev1.PullAlways))
+		Expect(*ktSpec.Args.Logginglevel).To(Equal(4))
+		Expect(*ktSpec.Args.Stitchuuid).To(BeFalse())
+		Expect(ktSpec.Resources.Limits).To(HaveKey(corev1.ResourceMemory))
+		Expect(ktSpec.KubeturboPodScheduling.NodeSelector).To(HaveKeyWithValue("kubernetes.io/os", "linux"))
+		Expect(ktSpec.RestAPIConfig.TurbonomicCredentialsSecretName).To(Equal("my-credentials"))
+		Expect(ktSpec.RestAPIConfig.OpsManagerUserName).To(BeNil())
+
+		Expect(ktSpec.ServerMeta.TurboServer).To(Equal("https://turbo.example.com"))
+		Expect(*ktSpec.ServerMeta.Version).To(Equal("8.14"))
+		Expect(*ktSpec.SdkProtocolConfig.RegistrationTimeoutSec).To(Equal(120))
+		Expect(ktSpec.HANodeConfig.NodeRoles).To(Equal(`"master","worker"`))
+		Expect(*ktSpec.TargetConfig.TargetName).To(Equal("prod"))
+		Expect(ktSpec.AnnotationWhitelist.ContainerSpec).To(BeNil())
+		Expect(*ktSpec.AnnotationWhitelist.Namespace).To(Equal("kubeturbo.io/.*"))
+
+		Expect(*ktSpec.Logging.Level).To(Equal(3))
+		Expect(*ktSpec.NodePoolSize.Max).To(Equal(500))
+		Expect(ktSpec.SystemWorkloadDetectors.NamespacePatterns).To(BeNil())
+		Expect(ktSpec.DaemonPodDetectors.PodNamePatterns).To(Equal([]string{"fluentd.*"}))
+	})
+
+	It("Reads the turbo.config written by the operator", func() {
+		kt := newTestKubeturbo(spec)
+		kt.spec.ServerMeta.Version = utils.AsPtr("8.14")
+		kt.spec.Logging.Level = utils.AsPtr(5)
+		cm := kt.configMap()
+		Expect(kt.mutateConfigMap(cm)).To(Succeed())
+
+		ktSpec := kubeturbosv1.KubeturboSpec{}
+		Expect(inferTurboConfig(&ktSpec, cm.Data["turbo.config"])).To(Succeed())
+		Expect(inferDynamicConfig(&ktSpec, cm.Data["turbo-autoreload.config"])).To(Succeed())
+		Expect(ktSpec.HANodeConfig.NodeRoles).To(Equal(spec.HANodeConfig.NodeRoles))
+		Expect(*ktSpec.Logging.Level).To(Equal(5))
+	})
+
+	It("Retires the legacy kubeturbo before the operator deploys its own", func() {
+		kt := adopting(newTestKubeturbo(spec, legacyObjects()...), "true")
+
+		// the legacy pod is still running
+		Expect(kt.adoptLegacyKubeturbo()).To(MatchError(constants.ErrRequeueOnDeletion))
+		condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted)
+		Expect(condition.Reason).To(Equal(reasonRetiringLegacy))
+		Expect(kt.spec.ServerMeta.TurboServer).To(Equal("https://turbo.example.com"))
+
+		stored := &kubeturbosv1.Kubeturbo{ObjectMeta: metav1.ObjectMeta{Name: kt.Name(), Namespace: kt.Namespace()}}
+		Expect(kt.Get(stored)).To(Succeed())
+		Expect(*stored.Spec.TargetConfig.TargetName).To(Equal("prod"))
+
+		dep := helmDeployment()
+		Expect(kt.Get(dep)).To(Succeed())
+		Expect(*dep.Spec.Replicas).To(BeZero())
+
+		Expect(kt.Client.Delete(kt.Context, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kubeturbo-abc", Namespace: "turbo"}})).To(Succeed())
+		Expect(kt.adoptLegacyKubeturbo()).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted)).To(BeTrue())
+
+		Expect(exists(kt, helmDeployment())).To(BeFalse())
+		Expect(exists(kt, helmConfigMap())).To(BeFalse())
+		Expect(exists(kt, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "turbo-all-binding-kubeturbo-turbo"}})).To(BeFalse())
+		Expect(exists(kt, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "kubeturbo-reader-kubeturbo-turbo"}})).To(BeFalse())
+		Expect(exists(kt, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "turbo-user-view"}})).To(BeTrue())
+		Expect(exists(kt, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.kubeturbo.v1", Namespace: "turbo"}})).To(BeFalse())
+
+		sa := kt.serviceAccount()
+		Expect(kt.Get(sa)).To(Succeed())
+		Expect(metav1.GetControllerOf(sa).UID).To(Equal(kt.Cr.UID))
+
+		// adopting is done once, the spec is not inferred again
+		Expect(kt.adoptLegacyKubeturbo()).To(Succeed())
+	})
+
+	// an older CR claiming the registration with the Turbo server
+	olderKubeturbo := func(turboServer string) *kubeturbosv1.Kubeturbo {
+		otherSpec := newTestSpec()
+		otherSpec.ServerMeta.TurboServer = turboServer
+		return &kubeturbosv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kubeturbo-old", Namespace: "turbo-old", UID: "5678",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: otherSpec,
+		}
+	}
+	expectLegacyUntouched := func(kt *kubeturbo) {
+		dep := helmDeployment()
+		Expect(kt.Get(dep)).To(Succeed())
+		Expect(dep.Spec.Replicas).To(Equal(helmDeployment().Spec.Replicas))
+		sa := kt.serviceAccount()
+		Expect(kt.Get(sa)).To(Succeed())
+		Expect(metav1.GetControllerOf(sa)).To(BeNil())
+		Expect(exists(kt, helmConfigMap())).To(BeTrue())
+		Expect(exists(kt, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "turbo-all-binding-kubeturbo-turbo"}})).To(BeTrue())
+		Expect(exists(kt, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.kubeturbo.v1", Namespace: "turbo"}})).To(BeTrue())
+	}
+
+	It("Leaves the legacy kubeturbo alone while the CR is conflicted", func() {
+		kt := adopting(newTestKubeturbo(spec, append(legacyObjects(), olderKubeturbo(spec.ServerMeta.TurboServer))...), "true")
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.reconcileKubeTurbo()).To(MatchError(constants.ErrReconcileBlocked))
+		Expect(meta.IsStatusConditionTrue(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted)).To(BeTrue())
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted)).To(BeNil())
+		expectLegacyUntouched(kt)
+	})
+
+	It("Leaves the legacy kubeturbo alone when the inferred spec conflicts", func() {
+		// the legacy kubeturbo registers with the server of the older CR
+		kt := adopting(newTestKubeturbo(spec, append(legacyObjects(), olderKubeturbo("https://turbo.example.com"))...), "true")
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.adoptLegacyKubeturbo()).To(MatchError(constants.ErrReconcileBlocked))
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted).Reason).To(Equal(reasonDuplicateRegistration))
+		expectLegacyUntouched(kt)
+	})
+
+	It("Moves the credentials of turbo.config to the credentials Secret", func() {
+		cm := helmConfigMap()
+		cm.Data["turbo.config"] = strings.Replace(cm.Data["turbo.config"],
+			`"opsManagerUserName": "", "opsManagerPassword": ""`, `"opsManagerUserName": "administrator", "opsManagerPassword": "secret"`, 1)
+		kt := adopting(newTestKubeturbo(spec, helmDeployment(), cm), "true")
+
+		Expect(kt.inferSpecFrom(helmDeployment())).To(Succeed())
+
+		stored := &kubeturbosv1.Kubeturbo{ObjectMeta: metav1.ObjectMeta{Name: kt.Name(), Namespace: kt.Namespace()}}
+		Expect(kt.Get(stored)).To(Succeed())
+		Expect(stored.Spec.RestAPIConfig.OpsManagerUserName).To(BeNil())
+		Expect(stored.Spec.RestAPIConfig.OpsManagerPassword).To(BeNil())
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-credentials", Namespace: "turbo"}}
+		Expect(kt.Get(secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"username": []byte("administrator"), "password": []byte("secret")}))
+		Expect(secret.OwnerReferences).To(BeEmpty())
+	})
+
+	It("Keeps the credentials already in the credentials Secret", func() {
+		cm := helmConfigMap()
+		cm.Data["turbo.config"] = strings.Replace(cm.Data["turbo.config"],
+			`"opsManagerUserName": "", "opsManagerPassword": ""`, `"opsManagerUserName": "administrator", "opsManagerPassword": "secret"`, 1)
+		existing := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-credentials", Namespace: "turbo"},
+			Data:       map[string][]byte{"username": []byte("turbo"), "password": []byte("rotated")},
+		}
+		kt := adopting(newTestKubeturbo(spec, helmDeployment(), cm, existing), "true")
+
+		Expect(kt.inferSpecFrom(helmDeployment())).To(Succeed())
+
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-credentials", Namespace: "turbo"}}
+		Expect(kt.Get(secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(existing.Data))
+	})
+
+	It("Keeps the ConfigMap the operator would create under the same name", func() {
+		dep := helmDeployment()
+		dep.Spec.Replicas = utils.AsPtr[int32](0)
+		dep.Spec.Template.Spec.Volumes[0].ConfigMap.Name = "turbo-config-kubeturbo-release"
+		cm := helmConfigMap()
+		cm.Name = "turbo-config-kubeturbo-release"
+		kt := adopting(newTestKubeturbo(spec, dep, cm), "kubeturbo")
+
+		Expect(kt.adoptLegacyKubeturbo()).To(Succeed())
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(metav1.GetControllerOf(cm).UID).To(Equal(kt.Cr.UID))
+	})
+
+	It("Leaves the CR alone when there is nothing to adopt", func() {
+		kt := adopting(newTestKubeturbo(spec), "true")
+
+		Expect(kt.adoptLegacyKubeturbo()).To(Succeed())
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted).Reason).To(Equal(reasonNothingToAdopt))
+	})
+
+	It("Blocks when several Deployments can be adopted", func() {
+		other := helmDeployment()
+		other.Name = "kubeturbo-2"
+		kt := adopting(newTestKubeturbo(spec, helmDeployment(), other), "true")
+
+		Expect(kt.adoptLegacyKubeturbo()).To(MatchError(constants.ErrReconcileBlocked))
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted).Reason).To(Equal(reasonAmbiguousAdopt))
+	})
+
+	It("Doesn't adopt a Deployment of another Kubeturbo CR", func() {
+		dep := helmDeployment()
+		dep.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: kubeturbosv1.GroupVersion.String(), Kind: "Kubeturbo", Name: "other", UID: "5678", Controller: utils.AsPtr(true),
+		}}
+		kt := adopting(newTestKubeturbo(spec, dep), "kubeturbo")
+
+		Expect(kt.adoptLegacyKubeturbo()).To(MatchError(constants.ErrReconcileBlocked))
+		Expect(meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionAdopted).Reason).To(Equal(reasonNotAdoptable))
+	})
+})
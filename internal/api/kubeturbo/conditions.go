@@ -0,0 +1,43 @@
+package kubeturbo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/metrics"
+)
+
+// Sets the condition on the CR and persists the status only if the condition changed,
+// so that reporting an unchanged state doesn't trigger another reconcile cycle
+func (kt *kubeturbo) setCondition(conditionType string, status metav1.ConditionStatus, reason, message string) error {
+	existing := meta.FindStatusCondition(kt.Cr.Status.Conditions, conditionType)
+	if existing != nil && existing.Status == status && existing.Reason == reason &&
+		existing.Message == message && existing.ObservedGeneration == kt.Cr.Generation {
+		return nil
+	}
+	meta.SetStatusCondition(&kt.Cr.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: kt.Cr.Generation,
+	})
+	return kt.UpdateStatus()
+}
+
+// Stops the reconcile cycle for the reason reported in the condition. A blocked CR is retried
+// periodically, so the block is only counted and announced when the condition changes to it
+func (kt *kubeturbo) blockReconcile(conditionType string, status metav1.ConditionStatus, reason, message string) error {
+	existing := meta.FindStatusCondition(kt.Cr.Status.Conditions, conditionType)
+	newlyBlocked := existing == nil || existing.Status != status || existing.Reason != reason
+	if err := kt.setCondition(conditionType, status, reason, message); err != nil {
+		return err
+	}
+	if newlyBlocked {
+		metrics.ValidationFailures.WithLabelValues(kt.Namespace(), kt.Name(), "Blocked").Inc()
+		kt.Event(corev1.EventTypeWarning, "ReconcileBlocked", message)
+	}
+	return constants.ErrReconcileBlocked
+}
@@ -0,0 +1,171 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+const (
+	// when a revision was last applied, orders the revisions from the newest to the oldest
+	revisionAppliedAnnotation = "kubeturbo.io/applied-at"
+	// fixed width, so that the timestamps sort chronologically as strings
+	revisionAppliedLayout = "2006-01-02T15:04:05.000000000Z"
+
+	defaultConfigRevisionHistoryLimit = 5
+)
+
+// The turbo.config and turbo-autoreload.config of kubeturbo, rendered from the spec or, while the CR
+// carries the rollback annotation, taken from the revision it names
+func (kt *kubeturbo) renderConfig() (map[string]string, error) {
+	if revision := kt.rollbackRevision(); revision != "" {
+		cm := kt.configRevision(revision)
+		if err := kt.Get(cm); errors.IsNotFound(err) {
+			return nil, fmt.Errorf("config revision %s set by the %s annotation doesn't exist", revision, constants.RollbackAnnotation)
+		} else if err != nil {
+			return nil, err
+		}
+		return map[string]string{
+			turboConfigKey:   cm.Data[turboConfigKey],
+			dynamicConfigKey: cm.Data[dynamicConfigKey],
+		}, nil
+	}
+
+	config, err := kt.buildKubeturboConfig()
+	if err != nil {
+		return nil, err
+	}
+	dynamicConfig, err := kt.buildKubeturboDynamicConfig()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		turboConfigKey:   string(config),
+		dynamicConfigKey: string(dynamicConfig),
+	}, nil
+}
+
+func (kt *kubeturbo) rollbackRevision() string {
+	return kt.Cr.GetAnnotations()[constants.RollbackAnnotation]
+}
+
+func (kt *kubeturbo) configRevision(revision string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: kt.configMapName() + "-" + revision, Namespace: kt.Namespace()}}
+}
+
+func (kt *kubeturbo) configRevisionHistoryLimit() int {
+	if kt.spec.ConfigRevisionHistoryLimit == nil {
+		return defaultConfigRevisionHistoryLimit
+	}
+	return max(int(*kt.spec.ConfigRevisionHistoryLimit), 1)
+}
+
+// The revision of the configuration, the hash of both the turbo.config and the turbo-autoreload.config.
+// Unlike the config hash, which covers the turbo.config kubeturbo restarts for, a change to the
+// turbo-autoreload.config alone makes a new revision as well
+func (kt *kubeturbo) getConfigRevision() (string, error) {
+	data, err := kt.renderConfig()
+	if err != nil {
+		return "", err
+	}
+
+	hash := fnv.New64()
+	for _, key := range []string{turboConfigKey, dynamicConfigKey} {
+		// the length keeps the content of one key from passing for the other
+		if _, err = fmt.Fprintf(hash, "%d:%s", len(data[key]), data[key]); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprint(hash.Sum64()), nil
+}
+
+// Keeps a copy of the configuration kubeturbo runs with in a ConfigMap of its own, so it can be rolled
+// back to, and drops the oldest revisions beyond the history limit. The revision names its content, so
+// the ConfigMap of a revision is immutable once written. Sets the revision in the CR status, the caller
+// updates the status
+func (kt *kubeturbo) recordConfigRevision(revision string) error {
+	data, err := kt.renderConfig()
+	if err != nil {
+		return err
+	}
+
+	// a revision applied again moves to the top of the history
+	applied := kt.Cr.Status.ConfigRevision != revision
+	cm := kt.configRevision(revision)
+	kt.SetControllerReference(cm)
+	if _, err := kt.CreateOrUpdate(cm, func() error {
+		cm.Labels = utils.NewMapBuilder[string, string]().
+			PutAll(kt.labels()).
+			Put(constants.ConfigRevisionLabelKey, revision).
+			Build()
+		if cm.Annotations == nil {
+			cm.Annotations = map[string]string{}
+		}
+		if _, found := cm.Annotations[revisionAppliedAnnotation]; applied || !found {
+			cm.Annotations[revisionAppliedAnnotation] = time.Now().UTC().Format(revisionAppliedLayout)
+		}
+		// only a revision not written yet takes the configuration
+		if cm.ResourceVersion == "" {
+			cm.Data = data
+			cm.Immutable = utils.AsPtr(true)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := kt.pruneConfigRevisions(revision); err != nil {
+		return err
+	}
+
+	if !applied {
+		return nil
+	}
+	if rollback := kt.rollbackRevision(); rollback != "" {
+		kt.Event(corev1.EventTypeNormal, "RolledBack", fmt.Sprintf("Rolled the configuration back to revision %s", rollback))
+	}
+	kt.Cr.Status.ConfigRevision = revision
+	return nil
+}
+
+// Deletes the oldest revisions beyond the history limit, never the applied one
+func (kt *kubeturbo) pruneConfigRevisions(current string) error {
+	var revisions corev1.ConfigMapList
+	if err := kt.List(&revisions,
+		client.InNamespace(kt.Namespace()),
+		client.MatchingLabels(kt.labels()),
+		client.HasLabels{constants.ConfigRevisionLabelKey},
+	); err != nil {
+		return err
+	}
+
+	sort.Slice(revisions.Items, func(i, j int) bool {
+		return revisions.Items[i].Annotations[revisionAppliedAnnotation] > revisions.Items[j].Annotations[revisionAppliedAnnotation]
+	})
+	// the applied revision counts towards the limit
+	kept := 1
+	for i := range revisions.Items {
+		cm := &revisions.Items[i]
+		if cm.Labels[constants.ConfigRevisionLabelKey] == current {
+			continue
+		}
+		if kept < kt.configRevisionHistoryLimit() {
+			kept++
+			continue
+		}
+		if err := kt.DeleteIfExists(cm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
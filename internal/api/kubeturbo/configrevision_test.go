@@ -0,0 +1,163 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Config revisions", func() {
+	var kt *kubeturbo
+
+	spec := newTestSpec()
+
+	BeforeEach(func() {
+		kt = newTestKubeturbo(spec)
+		Expect(kt.Get(kt.Cr)).To(Succeed())
+	})
+
+	// applies the configuration for the target name and returns its revision
+	apply := func(targetName string) string {
+		kt.spec.TargetConfig.TargetName = utils.AsPtr(targetName)
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+		revision, err := kt.getConfigRevision()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kt.Cr.Status.ConfigRevision).To(Equal(revision))
+		return revision
+	}
+
+	appliedConfig := func() map[string]string {
+		cm := kt.configMap()
+		Expect(kt.Get(cm)).To(Succeed())
+		return cm.Data
+	}
+
+	revisions := func() []string {
+		var cms corev1.ConfigMapList
+		Expect(kt.List(&cms, client.HasLabels{constants.ConfigRevisionLabelKey})).To(Succeed())
+		var names []string
+		for _, cm := range cms.Items {
+			names = append(names, cm.Labels[constants.ConfigRevisionLabelKey])
+		}
+		return names
+	}
+
+	It("keeps a copy of the applied configuration", func() {
+		revision := apply("first")
+		Expect(revision).NotTo(BeEmpty())
+
+		cm := kt.configRevision(revision)
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(appliedConfig()))
+		Expect(cm.Labels).To(HaveKeyWithValue(constants.ConfigRevisionLabelKey, revision))
+		Expect(cm.OwnerReferences).To(HaveLen(1))
+
+		Expect(apply("first")).To(Equal(revision))
+		Expect(revisions()).To(ConsistOf(revision))
+	})
+
+	It("records a change to the turbo-autoreload.config alone as a revision", func() {
+		first := apply("first")
+		configHash := kt.Cr.Status.ConfigHash
+
+		kt.spec.Logging.Level = utils.AsPtr(5)
+		second := apply("first")
+		Expect(second).NotTo(Equal(first))
+		Expect(revisions()).To(ConsistOf(first, second))
+		// kubeturbo reloads the turbo-autoreload.config without a restart
+		Expect(kt.Cr.Status.ConfigHash).To(Equal(configHash))
+
+		cm := kt.configRevision(first)
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Data[dynamicConfigKey]).NotTo(Equal(appliedConfig()[dynamicConfigKey]))
+	})
+
+	It("never rewrites the configuration of a revision", func() {
+		revision := apply("first")
+		cm := kt.configRevision(revision)
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Immutable).To(Equal(utils.AsPtr(true)))
+
+		// e.g. written by another version of the operator
+		cm.Data = map[string]string{turboConfigKey: "{}", dynamicConfigKey: "{}"}
+		Expect(kt.Client.Update(kt.Context, cm)).To(Succeed())
+		apply("second")
+		Expect(apply("first")).To(Equal(revision))
+
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(map[string]string{turboConfigKey: "{}", dynamicConfigKey: "{}"}))
+	})
+
+	It("drops the oldest revisions beyond the history limit", func() {
+		kt.spec.ConfigRevisionHistoryLimit = utils.AsPtr(int32(2))
+		first := apply("first")
+		second := apply("second")
+		Expect(revisions()).To(ConsistOf(first, second))
+
+		third := apply("third")
+		Expect(revisions()).To(ConsistOf(second, third))
+
+		// a revision applied again is the newest
+		Expect(apply("second")).To(Equal(second))
+		fourth := apply("fourth")
+		Expect(revisions()).To(ConsistOf(second, fourth))
+	})
+
+	It("rolls back to the revision named by the annotation", func() {
+		good := apply("good")
+		goodConfig := appliedConfig()
+		goodHash, err := kt.getKubeturboConfigHash()
+		Expect(err).NotTo(HaveOccurred())
+		apply("broken")
+		Expect(appliedConfig()).NotTo(Equal(goodConfig))
+
+		recorder := record.NewFakeRecorder(10)
+		kt.Recorder = recorder
+		kt.Cr.SetAnnotations(map[string]string{constants.RollbackAnnotation: good})
+		Expect(kt.Update(kt.Cr)).To(Succeed())
+		Expect(apply("broken")).To(Equal(good))
+		Expect(appliedConfig()).To(Equal(goodConfig))
+		Expect(recorder.Events).To(Receive(Equal("Normal Updated Updated ConfigMap " + kt.configMapName())))
+		// the revision moves to the top of the history
+		Expect(recorder.Events).To(Receive(Equal("Normal Updated Updated ConfigMap " + kt.configRevision(good).Name)))
+		Expect(recorder.Events).To(Receive(Equal("Normal RolledBack Rolled the configuration back to revision " + good)))
+
+		// kubeturbo restarts with the configuration of the revision
+		Expect(kt.getKubeturboConfigHash()).To(Equal(goodHash))
+	})
+
+	It("keeps the revision until kubeturbo restarts with the configuration", func() {
+		first := apply("first")
+
+		// open for a minute a year
+		kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}}
+		kt.spec.TargetConfig.TargetName = utils.AsPtr("second")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+		Expect(kt.Cr.Status.ConfigRevision).To(Equal(first))
+		Expect(revisions()).To(ConsistOf(first))
+
+		kt.spec.MaintenanceWindow = nil
+		kt.restartHeldBack = false
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError(constants.ErrRequeueOnDeletion))
+		second, err := kt.getConfigRevision()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kt.Cr.Status.ConfigRevision).To(Equal(second))
+		Expect(revisions()).To(ConsistOf(first, second))
+	})
+
+	It("fails to roll back to an unknown revision", func() {
+		kt.Cr.SetAnnotations(map[string]string{constants.RollbackAnnotation: "42"})
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(MatchError(ContainSubstring("config revision 42")))
+	})
+})
@@ -0,0 +1,150 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+)
+
+const (
+	reasonNoConflict             = "NoConflict"
+	reasonTargetNameConflict     = "TargetNameConflict"
+	reasonDuplicateRegistration  = "DuplicateRegistration"
+	reasonServiceAccountConflict = "ServiceAccountConflict"
+	reasonRoleBindingConflict    = "RoleBindingConflict"
+	reasonClusterRoleConflict    = "ClusterRoleConflict"
+)
+
+// A clash with an older Kubeturbo CR
+type conflict struct {
+	reason  string
+	message string
+}
+
+// Blocks the reconciliation if an older Kubeturbo CR in the cluster already claims what this
+// CR needs. The older CR wins, so that a new CR can't take over a working installation
+func (kt *kubeturbo) checkConflicts() error {
+	others, err := kt.olderKubeturbos()
+	if err != nil {
+		return err
+	}
+
+	var conflicts []conflict
+	for i := range others {
+		c, err := kt.conflictWith(&others[i])
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, c...)
+	}
+
+	if len(conflicts) == 0 {
+		return kt.setCondition(kubeturbosv1.ConditionConflicted, metav1.ConditionFalse, reasonNoConflict, "No conflict with other Kubeturbo CRs")
+	}
+
+	messages := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		messages = append(messages, c.message)
+	}
+	message := strings.Join(messages, "; ")
+	kt.logger.Info("Warning: " + message)
+	return kt.blockReconcile(kubeturbosv1.ConditionConflicted, metav1.ConditionTrue, conflicts[0].reason, message)
+}
+
+func (kt *kubeturbo) conflictWith(other *kubeturbosv1.Kubeturbo) ([]conflict, error) {
+	var conflicts []conflict
+	// evaluates the resource names of the other CR the same way they are generated for this CR
+	peer := &kubeturbo{
+		KubeturboRequest: NewKubeturboRequest(kt.Client, kt.APIReader, kt.Context, kt.Scheme, nil, other),
+		spec:             other.Spec,
+	}
+	owner := fmt.Sprintf("the Kubeturbo CR %s/%s", other.Namespace, other.Name)
+
+	if server := normalizedServerURL(kt.spec.ServerMeta.TurboServer); server != "" && server == normalizedServerURL(other.Spec.ServerMeta.TurboServer) {
+		conflicts = append(conflicts, conflict{
+			reason:  reasonDuplicateRegistration,
+			message: fmt.Sprintf("the cluster is already registered with %s by %s", kt.spec.ServerMeta.TurboServer, owner),
+		})
+	}
+
+	if kt.Namespace() == peer.Namespace() {
+		if kt.serviceAccountName() == peer.serviceAccountName() {
+			conflicts = append(conflicts, conflict{
+				reason:  reasonServiceAccountConflict,
+				message: fmt.Sprintf("the ServiceAccount %s is already used by %s", kt.serviceAccountName(), owner),
+			})
+		}
+	}
+
+	if name := kt.clusterRoleBinding().Name; name == peer.clusterRoleBinding().Name {
+		conflicts = append(conflicts, conflict{
+			reason:  reasonRoleBindingConflict,
+			message: fmt.Sprintf("the ClusterRoleBinding %s is already used by %s", name, owner),
+		})
+	}
+	// only the cluster roles generated by the operator are owned by a CR, the others may be shared
+	if kt.generatesClusterRole() && peer.generatesClusterRole() && kt.clusterRoleName() == peer.clusterRoleName() {
+		conflicts = append(conflicts, conflict{
+			reason:  reasonClusterRoleConflict,
+			message: fmt.Sprintf("the ClusterRole %s is already used by %s", kt.clusterRoleName(), owner),
+		})
+	}
+
+	if derivesTargetName(kt.spec) || derivesTargetName(other.Spec) {
+		if _, err := kt.clusterUID(); err != nil {
+			return nil, err
+		}
+	}
+	name := targetName(kt.spec, kt.clusterID)
+	if name != "" && name == targetName(other.Spec, kt.clusterID) {
+		conflicts = append(conflicts, conflict{
+			reason:  reasonTargetNameConflict,
+			message: fmt.Sprintf("the target name %s is already used by %s", name, owner),
+		})
+	}
+	return conflicts, nil
+}
+
+func normalizedServerURL(serverURL string) string {
+	return strings.TrimRight(strings.ToLower(strings.TrimSpace(serverURL)), "/")
+}
+
+// Lists the Kubeturbo CRs created before this one, oldest first. The CRs come from the cache of the
+// manager, which only holds the watched namespace if the operator is restricted to one
+func (kt *kubeturbo) olderKubeturbos() ([]kubeturbosv1.Kubeturbo, error) {
+	list := &kubeturbosv1.KubeturboList{}
+	if err := kt.List(list); err != nil {
+		return nil, err
+	}
+	var older []kubeturbosv1.Kubeturbo
+	for _, other := range list.Items {
+		// CRs on their way out don't hold on to anything anymore, and a CR that is
+		// blocked by a conflict itself never got to claim anything
+		if other.UID == kt.Cr.UID || other.DeletionTimestamp != nil ||
+			meta.IsStatusConditionTrue(other.Status.Conditions, kubeturbosv1.ConditionConflicted) {
+			continue
+		}
+		if isOlder(&other, kt.Cr) {
+			older = append(older, other)
+		}
+	}
+	sort.Slice(older, func(i, j int) bool {
+		return isOlder(&older[i], &older[j])
+	})
+	return older, nil
+}
+
+// Orders CRs by creation, CRs created within the same second by their namespaced name
+func isOlder(a, b *kubeturbosv1.Kubeturbo) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return types.NamespacedName{Namespace: a.Namespace, Name: a.Name}.String() <
+		types.NamespacedName{Namespace: b.Namespace, Name: b.Name}.String()
+}
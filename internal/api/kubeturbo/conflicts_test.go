@@ -0,0 +1,119 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Conflicts", func() {
+	spec := newTestSpec()
+	spec.RoleName = kubeturbosv1.RoleTypeAdmin
+
+	olderKubeturbo := func(name, namespace string, spec kubeturbosv1.KubeturboSpec) *kubeturbosv1.Kubeturbo {
+		return &kubeturbosv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: namespace, UID: "5678",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: spec,
+		}
+	}
+
+	conflictReason := func(kt *kubeturbo) string {
+		Expect(kt.checkConflicts()).To(MatchError(constants.ErrReconcileBlocked))
+		condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		return condition.Reason
+	}
+
+	It("Blocks a second CR registering the cluster with the same Turbo server", func() {
+		other := olderKubeturbo("kubeturbo-old", "turbo-old", spec)
+		other.Spec.ServiceAccountName = "other-user"
+		kt := newTestKubeturbo(spec, other)
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(conflictReason(kt)).To(Equal(reasonDuplicateRegistration))
+	})
+
+	It("Allows CRs registering the cluster with different Turbo servers", func() {
+		otherSpec := *spec.DeepCopy()
+		otherSpec.ServerMeta.TurboServer = "https://turbo-test.example.com"
+		kt := newTestKubeturbo(spec, olderKubeturbo("kubeturbo-old", "turbo-old", otherSpec))
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.checkConflicts()).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted)).To(BeTrue())
+	})
+
+	It("Blocks a CR sharing the ServiceAccount of an older CR in the same namespace", func() {
+		otherSpec := *spec.DeepCopy()
+		otherSpec.ServerMeta.TurboServer = "https://turbo-test.example.com"
+		kt := newTestKubeturbo(spec, olderKubeturbo("kubeturbo-old", "turbo", otherSpec))
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(conflictReason(kt)).To(Equal(reasonServiceAccountConflict))
+	})
+
+	It("Blocks a CR whose generated ClusterRoleBinding name is taken", func() {
+		// kubeturbo-release in turbo and kubeturbo in release-turbo generate the same names
+		otherSpec := *spec.DeepCopy()
+		otherSpec.ServerMeta.TurboServer = "https://turbo-test.example.com"
+		kt := newTestKubeturbo(spec, olderKubeturbo("kubeturbo", "release-turbo", otherSpec))
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(conflictReason(kt)).To(Equal(reasonRoleBindingConflict))
+		Expect(kt.Cr.Status.Conditions[0].Message).To(ContainSubstring("ClusterRole turbo-cluster-admin-kubeturbo-release-turbo"))
+	})
+
+	It("Ignores an older CR that is blocked by a conflict itself", func() {
+		other := olderKubeturbo("kubeturbo-old", "turbo-old", spec)
+		other.Status.Conditions = []metav1.Condition{{
+			Type: kubeturbosv1.ConditionConflicted, Status: metav1.ConditionTrue, Reason: reasonDuplicateRegistration,
+		}}
+		kt := newTestKubeturbo(spec, other)
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.checkConflicts()).To(Succeed())
+	})
+
+	It("Announces the block once", func() {
+		kt := newTestKubeturbo(spec, olderKubeturbo("kubeturbo-old", "turbo-old", spec))
+		kt.Cr.CreationTimestamp = metav1.Now()
+		recorder := record.NewFakeRecorder(10)
+		kt.Recorder = recorder
+
+		Expect(kt.checkConflicts()).To(MatchError(constants.ErrReconcileBlocked))
+		// the fake client doesn't keep the creation time across the status update
+		kt.Cr.CreationTimestamp = metav1.Now()
+		Expect(kt.checkConflicts()).To(MatchError(constants.ErrReconcileBlocked))
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(HavePrefix("Warning ReconcileBlocked the cluster is already registered"))
+	})
+
+	It("Leaves the finalizer of a ServiceAccount owned by another CR on teardown", func() {
+		sa := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "turbo-user", Namespace: "turbo", Finalizers: []string{serviceAccountFinalizer},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: kubeturbosv1.GroupVersion.String(), Kind: "Kubeturbo",
+					Name: "kubeturbo-old", UID: "5678", Controller: utils.AsPtr(true),
+				}},
+			},
+		}
+		kt := newTestKubeturbo(spec, sa)
+
+		Expect(kt.cleanUpClusterResources()).To(Succeed())
+		Expect(kt.Get(sa)).To(Succeed())
+		Expect(sa.Finalizers).To(ContainElement(serviceAccountFinalizer))
+	})
+})
@@ -0,0 +1,193 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/turboserver"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+const (
+	// the placeholder of the CRD default and the sample CRs
+	turboServerPlaceholder = "Turbo_server_URL"
+	// how often the Turbo server is probed at most while the spec stays the same
+	serverProbeInterval = 5 * time.Minute
+	// the keys of the username and password in the Turbo server credentials secret
+	credentialsUsernameKey = "username"
+	credentialsPasswordKey = "password"
+
+	reasonServerNotConfigured = "NotConfigured"
+)
+
+// When the Turbo server was last probed for each CR, and for which generation of the CR
+var serverProbes = struct {
+	sync.Mutex
+	byCR map[types.UID]serverProbe
+}{byCR: map[types.UID]serverProbe{}}
+
+type serverProbe struct {
+	generation int64
+	probedAt   time.Time
+}
+
+// Whether the Turbo server should be probed again. The probe blocks the reconcile
+// for up to the timeout, so it only runs when the spec changed or the last one is old
+func (kt *kubeturbo) serverProbeDue(now time.Time) bool {
+	serverProbes.Lock()
+	defer serverProbes.Unlock()
+	last, found := serverProbes.byCR[kt.Cr.UID]
+	return !found || last.generation != kt.Cr.Generation || now.Sub(last.probedAt) >= serverProbeInterval
+}
+
+func (kt *kubeturbo) recordServerProbe(now time.Time) {
+	serverProbes.Lock()
+	defer serverProbes.Unlock()
+	serverProbes.byCR[kt.Cr.UID] = serverProbe{generation: kt.Cr.Generation, probedAt: now}
+}
+
+func forgetServerProbe(kt *kubeturbosv1.Kubeturbo) {
+	serverProbes.Lock()
+	defer serverProbes.Unlock()
+	delete(serverProbes.byCR, kt.UID)
+}
+
+// Pre-flight check that the Turbo Server can be reached the same way kubeturbo reaches it, and
+// that it accepts the credentials kubeturbo registers with. The result is only reported in the
+// ServerReachable condition and never stops the reconcile cycle
+func (kt *kubeturbo) checkServerConnectivity() error {
+	if kt.Cr.Status.ServerURL != kt.spec.ServerMeta.TurboServer {
+		// the version of another Turbo server, persisted along with the condition below
+		kt.Cr.Status.ServerVersion, kt.Cr.Status.ServerURL = "", ""
+	}
+	if strings.Contains(kt.spec.ServerMeta.TurboServer, turboServerPlaceholder) {
+		message := fmt.Sprintf("serverMeta.turboServer is still set to the placeholder %s", kt.spec.ServerMeta.TurboServer)
+		return kt.setCondition(kubeturbosv1.ConditionServerReachable, metav1.ConditionUnknown, reasonServerNotConfigured, message)
+	}
+	now := time.Now()
+	if !kt.serverProbeDue(now) {
+		return nil
+	}
+
+	if err := kt.probeServer(); err != nil {
+		return err
+	}
+	kt.recordServerProbe(now)
+	return nil
+}
+
+func (kt *kubeturbo) probeServer() error {
+	c, err := kt.serverClient()
+	if err == nil {
+		var info *turboserver.VersionInfo
+		if info, err = c.GetVersion(kt.Context); err == nil {
+			// persisted along with the condition, whose message changes with the version
+			kt.Cr.Status.ServerVersion = info.Version
+			kt.Cr.Status.ServerURL = kt.spec.ServerMeta.TurboServer
+			err = kt.checkCredentials(c)
+		}
+	}
+	if err != nil {
+		kt.logger.Info("Turbo server is not reachable", "reason", turboserver.ReasonOf(err), "error", err.Error())
+		return kt.setCondition(kubeturbosv1.ConditionServerReachable, metav1.ConditionFalse, turboserver.ReasonOf(err), err.Error())
+	}
+	message := fmt.Sprintf("Turbo server %s responded with version %s", kt.spec.ServerMeta.TurboServer, kt.Cr.Status.ServerVersion)
+	return kt.setCondition(kubeturbosv1.ConditionServerReachable, metav1.ConditionTrue, turboserver.ReasonReachable, message)
+}
+
+func (kt *kubeturbo) serverClient() (*turboserver.Client, error) {
+	proxyURL, err := kt.operatorProxyURL()
+	if err != nil {
+		return nil, &turboserver.Error{Reason: turboserver.ReasonInvalidConfig, Err: err}
+	}
+	trustedCAs, err := kt.trustedCABundle()
+	if err != nil {
+		return nil, &turboserver.Error{Reason: turboserver.ReasonInvalidConfig, Err: err}
+	}
+	return turboserver.NewClient(turboserver.Options{
+		ServerURL:  kt.spec.ServerMeta.TurboServer,
+		ProxyURL:   proxyURL,
+		TrustedCAs: trustedCAs,
+		Timeout:    constants.TimeoutInSeconds * time.Second,
+	})
+}
+
+// Logs in with the username and password kubeturbo registers with. kubeturbo prefers the
+// credentials secret over the spec, the check is skipped for OAuth client credentials
+func (kt *kubeturbo) checkCredentials(c *turboserver.Client) error {
+	username, password := kt.spec.RestAPIConfig.OpsManagerUserName, kt.spec.RestAPIConfig.OpsManagerPassword
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: kt.Namespace(), Name: kt.spec.RestAPIConfig.TurbonomicCredentialsSecretName}
+	if err := kt.APIReader.Get(kt.Context, key, secret); err == nil {
+		if len(secret.Data[credentialsUsernameKey]) == 0 {
+			return nil
+		}
+		username = utils.AsPtr(string(secret.Data[credentialsUsernameKey]))
+		password = utils.AsPtr(string(secret.Data[credentialsPasswordKey]))
+	} else if !errors.IsNotFound(err) {
+		return &turboserver.Error{Reason: turboserver.ReasonInvalidConfig, Err: fmt.Errorf("unable to read the Turbo server credentials: %w", err)}
+	}
+	if username == nil || password == nil {
+		return nil
+	}
+	return c.Login(kt.Context, *username, *password)
+}
+
+// The proxy URL including the credentials from the proxy secret, if any
+func (kt *kubeturbo) operatorProxyURL() (string, error) {
+	proxy := kt.proxyURL()
+	secretRef := kt.spec.ServerMeta.ProxyConfig.SecretRef
+	if proxy == "" || secretRef == nil {
+		return proxy, nil
+	}
+
+	parsed, err := url.Parse(proxy)
+	if err != nil {
+		return "", fmt.Errorf("invalid serverMeta.proxyConfig.url %s", proxy)
+	}
+	secret := &corev1.Secret{}
+	if err := kt.APIReader.Get(kt.Context, client.ObjectKey{Namespace: kt.Namespace(), Name: secretRef.Name}, secret); err != nil {
+		return "", fmt.Errorf("unable to read the proxy credentials: %w", err)
+	}
+	parsed.User = url.UserPassword(string(secret.Data[proxyUsernameKey]), string(secret.Data[proxyPasswordKey]))
+	return parsed.String(), nil
+}
+
+// The PEM bundle configured in serverMeta.trustedCA, nil if there is none
+func (kt *kubeturbo) trustedCABundle() ([]byte, error) {
+	ca := kt.spec.ServerMeta.TrustedCA
+	key := client.ObjectKey{Namespace: kt.Namespace()}
+	switch {
+	case ca.SecretName != nil:
+		key.Name = *ca.SecretName
+		secret := &corev1.Secret{}
+		if err := kt.APIReader.Get(kt.Context, key, secret); err != nil {
+			return nil, fmt.Errorf("unable to read the trusted CA bundle: %w", err)
+		}
+		return secret.Data[kt.trustedCAKey()], nil
+	case ca.ConfigMapName != nil || kt.injectOpenShiftTrustedCA():
+		key.Name = kt.trustedCAConfigMap().Name
+		dataKey := trustedCAFileName
+		if ca.ConfigMapName != nil {
+			key.Name = *ca.ConfigMapName
+			dataKey = kt.trustedCAKey()
+		}
+		cm := &corev1.ConfigMap{}
+		if err := kt.APIReader.Get(kt.Context, key, cm); err != nil {
+			return nil, fmt.Errorf("unable to read the trusted CA bundle: %w", err)
+		}
+		return []byte(cm.Data[dataKey]), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,145 @@
+package kubeturbo
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/turboserver"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Connectivity", func() {
+	var server *httptest.Server
+	var requests int
+
+	BeforeEach(func() {
+		requests = 0
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Path == turboserver.LoginPath && r.PostFormValue("password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"version":"8.14.3"}`))
+		}))
+		// every test probes a server of its own
+		forgetServerProbe(&kubeturbosv1.Kubeturbo{ObjectMeta: metav1.ObjectMeta{UID: "1234"}})
+	})
+
+	// trusts the certificate of the server through a CA bundle secret
+	caBundleSecret := func() *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "turbo-server-ca", Namespace: "turbo"},
+			Data: map[string][]byte{
+				"ca-bundle.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+			},
+		}
+	}
+	trustedServerMeta := func() kubeturbosv1.KubeturboServerMeta {
+		return kubeturbosv1.KubeturboServerMeta{
+			TurboServer: server.URL,
+			TrustedCA:   kubeturbosv1.KubeturboTrustedCA{SecretName: utils.AsPtr("turbo-server-ca")},
+		}
+	}
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("The server certificate is signed by the trusted CA", func() {
+		It("Reports the server as reachable", func() {
+			caBundle := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "turbo-server-ca", Namespace: "turbo"},
+				Data: map[string]string{
+					"ca.pem": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+				},
+			}
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+				ServerMeta: kubeturbosv1.KubeturboServerMeta{
+					TurboServer: server.URL,
+					TrustedCA:   kubeturbosv1.KubeturboTrustedCA{ConfigMapName: utils.AsPtr("turbo-server-ca"), Key: "ca.pem"},
+				},
+			}, caBundle)
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+
+			condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionServerReachable)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Message).To(ContainSubstring("8.14.3"))
+		})
+	})
+
+	When("The server is probed again", func() {
+		It("Waits for a change of the spec or the probe interval", func() {
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{ServerMeta: trustedServerMeta()}, caBundleSecret())
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+			Expect(requests).To(Equal(1))
+
+			kt.Cr.Generation++
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+			Expect(requests).To(Equal(2))
+
+			Expect(kt.serverProbeDue(time.Now().Add(serverProbeInterval))).To(BeTrue())
+		})
+	})
+
+	When("The server URL is the placeholder", func() {
+		It("Reports the server as not configured without probing", func() {
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+				ServerMeta: kubeturbosv1.KubeturboServerMeta{TurboServer: "https://Turbo_server_URL"},
+			})
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+
+			condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionServerReachable)
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(condition.Reason).To(Equal("NotConfigured"))
+			Expect(requests).To(BeZero())
+		})
+	})
+
+	When("The server rejects the credentials", func() {
+		It("Reports invalid credentials", func() {
+			credentials := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "turbonomic-credentials", Namespace: "turbo"},
+				Data:       map[string][]byte{"username": []byte("administrator"), "password": []byte("wrong")},
+			}
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+				ServerMeta:    trustedServerMeta(),
+				RestAPIConfig: kubeturbosv1.KubeturboRestAPIConfig{TurbonomicCredentialsSecretName: "turbonomic-credentials"},
+			}, credentials, caBundleSecret())
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+
+			condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionServerReachable)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(turboserver.ReasonInvalidCredentials))
+		})
+	})
+
+	When("The server certificate isn't trusted", func() {
+		It("Reports a TLS error without failing the reconcile cycle", func() {
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+				ServerMeta: kubeturbosv1.KubeturboServerMeta{TurboServer: server.URL},
+			})
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+
+			condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionServerReachable)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(turboserver.ReasonTLSError))
+		})
+	})
+})
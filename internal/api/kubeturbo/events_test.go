@@ -0,0 +1,100 @@
+package kubeturbo
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		kt       *kubeturbo
+		recorder *record.FakeRecorder
+	)
+
+	spec := newTestSpec()
+
+	BeforeEach(func() {
+		kt = newTestKubeturbo(spec)
+		recorder = record.NewFakeRecorder(10)
+		kt.Recorder = recorder
+	})
+
+	It("records the creation, update and deletion of a child object", func() {
+		Expect(kt.reconcileStep("createOrUpdateServiceAccount")).To(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Normal Created Created ServiceAccount " + kt.serviceAccountName())))
+
+		Expect(kt.reconcileStep("createOrUpdateServiceAccount")).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+
+		kt.spec.Metrics.Enabled = utils.AsPtr(true)
+		Expect(kt.reconcileStep("createOrUpdateMetricsService")).To(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Normal Created Created Service " + kt.metricsServiceName())))
+
+		kt.spec.Metrics.Enabled = utils.AsPtr(false)
+		Expect(kt.reconcileStep("createOrUpdateMetricsService")).To(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted Service " + kt.metricsServiceName())))
+
+		// nothing left to delete
+		Expect(kt.reconcileStep("createOrUpdateMetricsService")).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("records the update of a child object", func() {
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal Created Created ConfigMap")))
+
+		kt.spec.TargetConfig.TargetName = utils.AsPtr("renamed")
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Normal Updated Updated ConfigMap " + kt.configMapName())))
+	})
+
+	It("records a restart triggered by a configuration change", func() {
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Normal Created Created Deployment " + kt.Name())))
+
+		kt.Cr.Status.ConfigHash = "outdated"
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError(constants.ErrRequeueOnDeletion))
+		// the configuration kubeturbo restarts with becomes a revision
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal Created Created ConfigMap " + kt.configMapName() + "-")))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal Restarting")))
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted Deployment " + kt.Name())))
+	})
+
+	It("records the deletion of a child object recreated on purpose", func() {
+		Expect(kt.reconcileStep("createOrUpdateClusterRoleBinding")).To(Succeed())
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal Created Created ClusterRoleBinding")))
+
+		// the role ref of a binding can't change
+		kt.spec.RoleName = kubeturbosv1.RoleTypeReadOnly
+		Expect(kt.reconcileStep("createOrUpdateClusterRoleBinding")).To(MatchError(constants.ErrRequeueOnDeletion))
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted ClusterRoleBinding " + kt.clusterRoleBinding().Name)))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("records the failed creation and deletion of a child object", func() {
+		kt.Client = interceptor.NewClient(kt.Client.(client.WithWatch), interceptor.Funcs{
+			Create: func(context.Context, client.WithWatch, client.Object, ...client.CreateOption) error {
+				return fmt.Errorf("quota exceeded")
+			},
+			Delete: func(context.Context, client.WithWatch, client.Object, ...client.DeleteOption) error {
+				return fmt.Errorf("forbidden")
+			},
+		})
+
+		Expect(kt.reconcileStep("createOrUpdateServiceAccount")).NotTo(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Warning FailedCreate Failed to create ServiceAccount " + kt.serviceAccountName() + ": quota exceeded")))
+
+		Expect(kt.DeleteIfExists(kt.serviceAccount())).NotTo(Succeed())
+		Expect(recorder.Events).To(Receive(Equal("Warning FailedDelete Failed to delete ServiceAccount " + kt.serviceAccountName() + ": forbidden")))
+	})
+})
@@ -0,0 +1,74 @@
+package kubeturbo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+func TestKubeturbo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kubeturbo Suite")
+}
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(kubeturbosv1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+// A spec with the fields set that the CRD defaults and the operator resolves before the builders run
+func newTestSpec() kubeturbosv1.KubeturboSpec {
+	return kubeturbosv1.KubeturboSpec{
+		ServerMeta:         kubeturbosv1.KubeturboServerMeta{TurboServer: "https://turbo.example.com", Version: utils.AsPtr("8.14.3")},
+		ServiceAccountName: "turbo-user",
+		RoleName:           kubeturbosv1.RoleTypeClusterAdmin,
+		RoleBinding:        "turbo-all-binding",
+		Image:              kubeturbosv1.KubeturboImage{Repository: "icr.io/cpopen/turbonomic/kubeturbo", Tag: utils.AsPtr("8.14.3")},
+		HANodeConfig:       kubeturbosv1.KubeturboHANodeConfig{NodeRoles: `"master"`},
+	}
+}
+
+// Builds a kubeturbo reconcile request backed by a fake client holding the given objects
+func newTestKubeturbo(spec kubeturbosv1.KubeturboSpec, objs ...client.Object) *kubeturbo {
+	scheme := newTestScheme()
+	cr := &kubeturbosv1.Kubeturbo{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeturbo-release", Namespace: "turbo", UID: "1234"},
+		Spec:       spec,
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objs, cr)...).
+		WithStatusSubresource(cr).
+		Build()
+	ctx := context.Background()
+	kr := NewKubeturboRequest(c, c, ctx, scheme, nil, cr)
+	return &kubeturbo{KubeturboRequest: kr, spec: cr.Spec, logger: log.FromContext(ctx)}
+}
+
+// Runs the reconcile step of the child object, e.g. createOrUpdateDeployment
+func (kt *kubeturbo) reconcileStep(step string) error {
+	children, err := kt.childObjects()
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if child.step == step {
+			return kt.reconcileChildObject(child)
+		}
+	}
+	return fmt.Errorf("no reconcile step %s", step)
+}
@@ -0,0 +1,142 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+)
+
+// Whether the maintenance window is open at the given time, and when it opens next if it isn't.
+// Without a maintenance window the changes are never held back
+func (kt *kubeturbo) maintenanceWindow(now time.Time) (bool, time.Time, error) {
+	window := kt.spec.MaintenanceWindow
+	if window == nil {
+		return true, time.Time{}, nil
+	}
+	open, opening, err := cronWindow(window.Schedule, window.TimeZone, window.Duration.Duration, now)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid maintenanceWindow: %w", err)
+	}
+	return open, opening, nil
+}
+
+// The image of the kubeturbo container, empty if the Deployment doesn't exist yet
+func kubeturboImage(dep *appsv1.Deployment) string {
+	return templateImage(&dep.Spec.Template)
+}
+
+func templateImage(template *corev1.PodTemplateSpec) string {
+	for _, container := range template.Spec.Containers {
+		if container.Name == constants.KubeturboContainerName {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// The hash of a pod template, kept on the Deployment to tell if the template kubeturbo runs with is
+// outdated, which comparing it with the live template can't since it carries the defaults of the API server
+func podTemplateHash(template *corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	hash := fnv.New64()
+	if _, err := hash.Write(data); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(hash.Sum64()), nil
+}
+
+// Keeps the pod template kubeturbo runs with until the maintenance window, since any change to it
+// restarts kubeturbo
+func (kt *kubeturbo) holdBackTemplate(dep *appsv1.Deployment, live *corev1.PodTemplateSpec, liveHash string) error {
+	if live == nil || dep.Annotations[constants.PodTemplateHashAnnotation] == liveHash {
+		return nil
+	}
+	otherChanges := true
+	if liveImage, image := templateImage(live), kubeturboImage(dep); image != liveImage {
+		kt.pendingChanges = append(kt.pendingChanges, fmt.Sprintf("upgrade of the kubeturbo image from %s to %s", liveImage, image))
+		// whether the image is the only change
+		template := dep.Spec.Template.DeepCopy()
+		for i := range template.Spec.Containers {
+			if template.Spec.Containers[i].Name == constants.KubeturboContainerName {
+				template.Spec.Containers[i].Image = liveImage
+			}
+		}
+		hash, err := podTemplateHash(template)
+		if err != nil {
+			return err
+		}
+		otherChanges = hash != liveHash
+	}
+	if otherChanges {
+		kt.pendingChanges = append(kt.pendingChanges, "update of the kubeturbo pod template")
+	}
+
+	dep.Spec.Template = *live
+	if liveHash == "" {
+		delete(dep.Annotations, constants.PodTemplateHashAnnotation)
+	} else {
+		dep.Annotations[constants.PodTemplateHashAnnotation] = liveHash
+	}
+	return nil
+}
+
+// Keeps the turbo.config kubeturbo runs with until the maintenance window, so that a kubeturbo pod
+// restarted meanwhile doesn't pick up the changes either. The held back restart lists them as pending
+func holdBackTurboConfig(cm *corev1.ConfigMap, liveConfig string) {
+	cm.Data[turboConfigKey] = liveConfig
+}
+
+// Mutates the kubeturbo ConfigMap, holding back the turbo.config outside the maintenance window
+func (kt *kubeturbo) mutateConfigMapInWindow(cm *corev1.ConfigMap, inWindow bool) error {
+	liveConfig, found := cm.Data[turboConfigKey]
+	if err := kt.mutateConfigMap(cm); err != nil {
+		return err
+	}
+	if !inWindow && found {
+		holdBackTurboConfig(cm, liveConfig)
+	}
+	return nil
+}
+
+// Mutates the kubeturbo Deployment, holding back the pod template outside the maintenance window
+func (kt *kubeturbo) mutateDeploymentInWindow(dep *appsv1.Deployment, inWindow bool) error {
+	var live *corev1.PodTemplateSpec
+	if dep.ResourceVersion != "" {
+		live = dep.Spec.Template.DeepCopy()
+	}
+	liveHash := dep.Annotations[constants.PodTemplateHashAnnotation]
+	if err := kt.mutateDeployment(dep); err != nil {
+		return err
+	}
+	if !inWindow {
+		return kt.holdBackTemplate(dep, live, liveHash)
+	}
+	return nil
+}
+
+// Shows the changes waiting for the maintenance window in the CR status, they are retried once it opens
+func (kt *kubeturbo) updateMaintenanceStatus(nextWindow time.Time) error {
+	var next *metav1.Time
+	if len(kt.pendingChanges) > 0 {
+		next = &metav1.Time{Time: nextWindow}
+		kt.requeueAt(nextWindow, fmt.Sprintf("%d changes wait for the maintenance window", len(kt.pendingChanges)))
+	}
+	status := &kt.Cr.Status
+	if reflect.DeepEqual(status.PendingChanges, kt.pendingChanges) && status.NextMaintenanceWindow.Equal(next) {
+		return nil
+	}
+	status.PendingChanges = kt.pendingChanges
+	status.NextMaintenanceWindow = next
+	return kt.UpdateStatus()
+}
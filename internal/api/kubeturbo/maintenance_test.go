@@ -0,0 +1,173 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Maintenance window", func() {
+	var kt *kubeturbo
+
+	spec := newTestSpec()
+
+	// open for a minute a year
+	closedWindow := &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}}
+	openWindow := &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "* * * * *"}
+
+	BeforeEach(func() {
+		kt = newTestKubeturbo(spec)
+		Expect(kt.Get(kt.Cr)).To(Succeed())
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+	})
+
+	// the state of a reconcile cycle is dropped at its end
+	nextReconcile := func() {
+		kt = &kubeturbo{KubeturboRequest: kt.KubeturboRequest, spec: kt.spec, logger: kt.logger}
+	}
+
+	liveDeployment := func() *appsv1.Deployment {
+		dep := kt.deployment()
+		Expect(kt.Get(dep)).To(Succeed())
+		return dep
+	}
+
+	Describe("maintenanceWindow", func() {
+		// a Saturday
+		now := time.Date(2024, time.June, 1, 3, 30, 0, 0, time.UTC)
+
+		It("is open within the duration after an opening", func() {
+			kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 2 * time.Hour}}
+			open, _, err := kt.maintenanceWindow(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(open).To(BeTrue())
+		})
+
+		It("opens next at the following opening once closed", func() {
+			kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 2 * * 6"}
+			open, next, err := kt.maintenanceWindow(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(open).To(BeFalse())
+			Expect(next).To(Equal(time.Date(2024, time.June, 8, 2, 0, 0, 0, time.UTC)))
+		})
+
+		It("follows the time zone of the schedule", func() {
+			kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 5 * * 6", TimeZone: "Europe/Paris"}
+			open, _, err := kt.maintenanceWindow(now)
+			Expect(err).NotTo(HaveOccurred())
+			// 5am in Paris is 3am UTC in summer
+			Expect(open).To(BeTrue())
+		})
+
+		It("is always open without a maintenance window", func() {
+			open, _, err := kt.maintenanceWindow(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(open).To(BeTrue())
+		})
+
+		It("rejects an invalid schedule", func() {
+			kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "every saturday"}
+			_, _, err := kt.maintenanceWindow(now)
+			Expect(err).To(MatchError(ContainSubstring("invalid maintenanceWindow")))
+		})
+	})
+
+	It("holds back a restart until the window opens", func() {
+		oldHash := kt.Cr.Status.ConfigHash
+		kt.spec.MaintenanceWindow = closedWindow
+		kt.spec.TargetConfig.TargetName = utils.AsPtr("changed")
+
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+		liveDeployment()
+		Expect(kt.Cr.Status.ConfigHash).To(Equal(oldHash))
+		Expect(kt.Cr.Status.PendingChanges).To(ConsistOf(ContainSubstring("restart of kubeturbo")))
+		Expect(kt.Cr.Status.NextMaintenanceWindow).NotTo(BeNil())
+
+		err := kt.awaitSchedule()
+		Expect(err).To(BeAssignableToTypeOf(&constants.RequeueAfterError{}))
+		Expect(err.(*constants.RequeueAfterError).Delay).To(BeNumerically("~", time.Until(kt.Cr.Status.NextMaintenanceWindow.Time), time.Second))
+
+		// the next reconcile, once the window opened
+		nextReconcile()
+		kt.spec.MaintenanceWindow = openWindow
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError(constants.ErrRequeueOnDeletion))
+		Expect(kt.Cr.Status.ConfigHash).NotTo(Equal(oldHash))
+	})
+
+	It("holds back an image upgrade and applies the other changes", func() {
+		kt.spec.MaintenanceWindow = closedWindow
+		kt.spec.Image.Tag = utils.AsPtr("8.15.0")
+		kt.spec.ReplicaCount = utils.AsPtr(int32(0))
+
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		dep := liveDeployment()
+		Expect(kubeturboImage(dep)).To(Equal("icr.io/cpopen/turbonomic/kubeturbo:8.14.3"))
+		Expect(*dep.Spec.Replicas).To(BeZero())
+		Expect(kt.Cr.Status.PendingChanges).To(ConsistOf(
+			"upgrade of the kubeturbo image from icr.io/cpopen/turbonomic/kubeturbo:8.14.3 to icr.io/cpopen/turbonomic/kubeturbo:8.15.0",
+		))
+
+		nextReconcile()
+		kt.spec.MaintenanceWindow = openWindow
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kubeturboImage(liveDeployment())).To(Equal("icr.io/cpopen/turbonomic/kubeturbo:8.15.0"))
+		Expect(kt.Cr.Status.PendingChanges).To(BeEmpty())
+		Expect(kt.Cr.Status.NextMaintenanceWindow).To(BeNil())
+		Expect(kt.awaitSchedule()).To(Succeed())
+	})
+
+	It("holds back the other changes of the pod template", func() {
+		kt.spec.MaintenanceWindow = closedWindow
+		kt.spec.Args.Logginglevel = utils.AsPtr(5)
+		kt.spec.Resources = &kubeturbosv1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}}
+
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		container := liveDeployment().Spec.Template.Spec.Containers[0]
+		Expect(container.Args).NotTo(ContainElement("--v=5"))
+		Expect(container.Resources.Limits).To(BeEmpty())
+		Expect(kt.Cr.Status.PendingChanges).To(ConsistOf("update of the kubeturbo pod template"))
+
+		// nothing is applied meanwhile
+		nextReconcile()
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(liveDeployment().Spec.Template.Spec.Containers[0].Args).NotTo(ContainElement("--v=5"))
+
+		nextReconcile()
+		kt.spec.MaintenanceWindow = openWindow
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(liveDeployment().Spec.Template.Spec.Containers[0].Args).To(ContainElement("--v=5"))
+		Expect(kt.Cr.Status.PendingChanges).To(BeEmpty())
+	})
+
+	It("holds back the turbo.config until the restart", func() {
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		cm := kt.configMap()
+		Expect(kt.Get(cm)).To(Succeed())
+		liveConfig := cm.Data["turbo.config"]
+
+		kt.spec.MaintenanceWindow = closedWindow
+		kt.spec.TargetConfig.TargetName = utils.AsPtr("changed")
+		kt.spec.Logging.Level = utils.AsPtr(5)
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Data["turbo.config"]).To(Equal(liveConfig))
+		// kubeturbo reloads it without a restart
+		Expect(cm.Data["turbo-autoreload.config"]).To(ContainSubstring(`"logging": 5`))
+
+		kt.spec.MaintenanceWindow = openWindow
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Data["turbo.config"]).To(ContainSubstring("changed"))
+	})
+})
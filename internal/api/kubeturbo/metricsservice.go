@@ -0,0 +1,88 @@
+package kubeturbo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+const (
+	metricsPortName = "metrics"
+	metricsPath     = "/metrics"
+)
+
+// The ServiceMonitor of the Prometheus operator, which isn't installed on every cluster
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+func (kt *kubeturbo) metricsEnabled() bool {
+	return kt.spec.Metrics.Enabled != nil && *kt.spec.Metrics.Enabled
+}
+
+func (kt *kubeturbo) metricsServiceName() string {
+	return kt.Name() + "-metrics"
+}
+
+func (kt *kubeturbo) metricsService() *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: kt.metricsServiceName(), Namespace: kt.Namespace()}}
+}
+
+// Exposes the metrics endpoint of the kubeturbo pod
+func (kt *kubeturbo) mutateMetricsService(svc *corev1.Service) error {
+	svc.ObjectMeta.Labels = kt.labels()
+	svc.Spec.Selector = kt.labels()
+	svc.Spec.Ports = []corev1.ServicePort{{
+		Name:       metricsPortName,
+		Protocol:   corev1.ProtocolTCP,
+		Port:       constants.KubeturboPort,
+		TargetPort: intstr.FromInt(constants.KubeturboPort),
+	}}
+	return nil
+}
+
+func (kt *kubeturbo) serviceMonitor() *unstructured.Unstructured {
+	sm := &unstructured.Unstructured{}
+	sm.SetGroupVersionKind(serviceMonitorGVK)
+	sm.SetName(kt.metricsServiceName())
+	sm.SetNamespace(kt.Namespace())
+	return sm
+}
+
+// Whether the Prometheus operator is installed in the cluster
+func (kt *kubeturbo) serviceMonitorAvailable() (bool, error) {
+	_, err := kt.Client.RESTMapper().RESTMapping(serviceMonitorGVK.GroupKind(), serviceMonitorGVK.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Has Prometheus scrape the metrics Service
+func (kt *kubeturbo) mutateServiceMonitor(sm *unstructured.Unstructured) error {
+	sm.SetLabels(utils.NewMapBuilder[string, string]().
+		PutAll(kt.spec.Metrics.ServiceMonitorLabels).
+		PutAll(kt.labels()).
+		Build())
+
+	endpoint := map[string]interface{}{
+		"port":   metricsPortName,
+		"path":   metricsPath,
+		"scheme": "http",
+	}
+	if kt.spec.Metrics.Interval != "" {
+		endpoint["interval"] = kt.spec.Metrics.Interval
+	}
+	matchLabels := map[string]interface{}{}
+	for k, v := range kt.labels() {
+		matchLabels[k] = v
+	}
+	return unstructured.SetNestedField(sm.Object, map[string]interface{}{
+		"endpoints": []interface{}{endpoint},
+		"selector":  map[string]interface{}{"matchLabels": matchLabels},
+	}, "spec")
+}
@@ -0,0 +1,96 @@
+package kubeturbo
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Metrics Service", func() {
+	enabled := kubeturbosv1.KubeturboSpec{Metrics: kubeturbosv1.KubeturboMetrics{
+		Enabled:              utils.AsPtr(true),
+		Interval:             "30s",
+		ServiceMonitorLabels: map[string]string{"release": "prometheus"},
+	}}
+
+	// Registers the ServiceMonitor kind as if the Prometheus operator was installed
+	installPrometheusOperator := func(kt *kubeturbo) {
+		kt.Client.RESTMapper().(*meta.DefaultRESTMapper).Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+	}
+
+	reconcile := func(kt *kubeturbo) {
+		Expect(kt.reconcileStep("createOrUpdateMetricsService")).To(Succeed())
+		Expect(kt.reconcileStep("createOrUpdateServiceMonitor")).To(Succeed())
+	}
+
+	It("creates nothing by default", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{})
+		installPrometheusOperator(kt)
+		reconcile(kt)
+
+		Expect(errors.IsNotFound(kt.Get(kt.metricsService()))).To(BeTrue())
+		Expect(errors.IsNotFound(kt.Get(kt.serviceMonitor()))).To(BeTrue())
+	})
+
+	It("exposes the metrics of the kubeturbo pod", func() {
+		kt := newTestKubeturbo(enabled)
+		reconcile(kt)
+
+		svc := kt.metricsService()
+		Expect(kt.Get(svc)).To(Succeed())
+		Expect(svc.Labels).To(Equal(kt.labels()))
+		Expect(svc.Spec.Selector).To(Equal(kt.labels()))
+		Expect(svc.Spec.Ports).To(ConsistOf(HaveField("Port", int32(constants.KubeturboPort))))
+		Expect(svc.OwnerReferences).To(ConsistOf(HaveField("UID", kt.Cr.UID)))
+	})
+
+	It("skips the ServiceMonitor without the Prometheus operator", func() {
+		kt := newTestKubeturbo(enabled)
+		reconcile(kt)
+
+		available, err := kt.serviceMonitorAvailable()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(available).To(BeFalse())
+	})
+
+	It("creates a ServiceMonitor with the Prometheus operator", func() {
+		kt := newTestKubeturbo(enabled)
+		installPrometheusOperator(kt)
+		reconcile(kt)
+
+		sm := kt.serviceMonitor()
+		Expect(kt.Get(sm)).To(Succeed())
+		Expect(sm.GetLabels()).To(HaveKeyWithValue("release", "prometheus"))
+		Expect(sm.GetLabels()).To(HaveKeyWithValue(constants.ManagedByLabelKey, constants.OperatorName))
+		Expect(sm.GetOwnerReferences()).To(ConsistOf(HaveField("UID", kt.Cr.UID)))
+
+		endpoints, _, err := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(ConsistOf(And(
+			HaveKeyWithValue("port", metricsPortName),
+			HaveKeyWithValue("interval", "30s"),
+		)))
+		matchLabels, _, err := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matchLabels).To(Equal(kt.labels()))
+	})
+
+	It("removes the Service and ServiceMonitor once the metrics are disabled", func() {
+		kt := newTestKubeturbo(enabled)
+		installPrometheusOperator(kt)
+		reconcile(kt)
+
+		kt.spec.Metrics.Enabled = utils.AsPtr(false)
+		reconcile(kt)
+
+		Expect(errors.IsNotFound(kt.Get(&corev1.Service{ObjectMeta: kt.metricsService().ObjectMeta}))).To(BeTrue())
+		Expect(errors.IsNotFound(kt.Get(kt.serviceMonitor()))).To(BeTrue())
+	})
+})
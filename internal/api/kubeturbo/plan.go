@@ -0,0 +1,298 @@
+package kubeturbo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+)
+
+// Operations the reconcile cycle would run on an object
+const (
+	planCreate    = "create"
+	planUpdate    = "update"
+	planRecreate  = "recreate"
+	planDelete    = "delete"
+	planUnchanged = "unchanged"
+)
+
+const planSummaryKey = "summary"
+
+// The change the reconcile cycle would apply to an object
+type plannedChange struct {
+	kind      string
+	name      string
+	operation string
+	note      string
+	diff      string
+}
+
+// Runs the builders of the reconcile cycle against the live objects and publishes the
+// resulting diff to the plan ConfigMap, without changing anything else
+func Plan(ctx context.Context, c client.Client, apiReader client.Reader, scheme *runtime.Scheme, recorder record.EventRecorder, ktV1 *kubeturbosv1.Kubeturbo) error {
+	logger := log.FromContext(ctx).WithName("Plan-cycle")
+	// builders may delete objects or update the CR status, which the dry run client discards.
+	// No events either, since nothing changes
+	kr := NewKubeturboRequest(client.NewDryRunClient(c), apiReader, ctx, scheme, nil, ktV1)
+	kt := kubeturbo{KubeturboRequest: kr, spec: kr.Cr.Spec, logger: logger}
+
+	changes, err := kt.plan()
+	if err != nil {
+		return err
+	}
+
+	publisher := kubeturbo{KubeturboRequest: NewKubeturboRequest(c, apiReader, ctx, scheme, recorder, ktV1), spec: ktV1.Spec, logger: logger}
+	return publisher.publishPlan(changes)
+}
+
+func (kt *kubeturbo) plan() ([]plannedChange, error) {
+	if err := kt.resolveTargetName(); err != nil {
+		return nil, err
+	}
+	if err := kt.resolveServerVersion(); err != nil {
+		return nil, err
+	}
+	freeze, err := kt.actionFreeze(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	kt.actionsFrozen = freeze.frozen
+
+	if err := kt.validateTrustedCA(); err != nil {
+		return nil, err
+	}
+
+	// changes outside the maintenance window are held back the same way the reconcile cycle does
+	inWindow, nextWindow, err := kt.maintenanceWindow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	children, err := kt.childObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []plannedChange
+	for _, child := range children {
+		if child.available != nil {
+			if available, err := child.available(); err != nil {
+				return nil, err
+			} else if !available {
+				continue
+			}
+		}
+		if !child.enabled {
+			if !child.owned {
+				continue
+			}
+			change, err := planDeletion(kt, child.obj)
+			if err != nil {
+				return nil, err
+			}
+			if change != nil {
+				changes = append(changes, *change)
+			}
+			continue
+		}
+
+		if child.owned {
+			kt.SetControllerReference(child.obj)
+		}
+		change, err := planObject(kt, child.obj, child.mutate)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := child.obj.(*appsv1.Deployment); ok {
+			if err := kt.planRestart(&change, inWindow, nextWindow); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Mirrors the restart of kubeturbo in restartForConfigChange and the changes held back for the
+// maintenance window in the change of the Deployment
+func (kt *kubeturbo) planRestart(change *plannedChange, inWindow bool, nextWindow time.Time) error {
+	newConfigMapHash, err := kt.getKubeturboConfigHash()
+	if err != nil {
+		return err
+	}
+	if oldConfigMapHash := kt.Cr.Status.ConfigHash; oldConfigMapHash != "" && oldConfigMapHash != newConfigMapHash && change.operation != planCreate {
+		change.operation = planRecreate
+		change.note = "kubeturbo restarts to pick up the changed turbo.config"
+		if window := kt.spec.RestartSettleWindow; window != nil && window.Duration > 0 {
+			change.note += fmt.Sprintf(" once it stays unchanged for %s", window.Duration)
+		}
+		if !inWindow {
+			change.note += fmt.Sprintf(" in the maintenance window opening at %s", nextWindow.Format(time.RFC3339))
+		}
+	}
+	if len(kt.pendingChanges) > 0 {
+		if change.note != "" {
+			change.note += "; "
+		}
+		change.note += fmt.Sprintf("%s held back until the maintenance window opening at %s",
+			strings.Join(kt.pendingChanges, ", "), nextWindow.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Applies the mutate function to a copy of the live object, the same way CreateOrUpdate would
+func planObject(kt *kubeturbo, obj client.Object, mutate func(client.Object) error) (plannedChange, error) {
+	gvk, err := apiutil.GVKForObject(obj, kt.Scheme)
+	if err != nil {
+		return plannedChange{}, err
+	}
+	change := plannedChange{kind: gvk.Kind, name: obj.GetName()}
+	template := obj.DeepCopyObject().(client.Object)
+
+	live := obj.DeepCopyObject().(client.Object)
+	if err := kt.Get(live); err != nil {
+		if !errors.IsNotFound(err) {
+			return change, err
+		}
+		if err := mutate(obj); err != nil {
+			return change, err
+		}
+		change.operation = planCreate
+		change.diff, err = diffObjects(nil, obj)
+		return change, err
+	}
+
+	desired := live.DeepCopyObject().(client.Object)
+	err = mutate(desired)
+	if err == constants.ErrRequeueOnDeletion {
+		// the object can't be updated in place, the next cycle creates it from scratch
+		desired = template
+		if err := mutate(desired); err != nil {
+			return change, err
+		}
+		change.operation = planRecreate
+		change.note = "immutable fields change"
+	} else if err != nil {
+		return change, err
+	} else if equality.Semantic.DeepEqual(live, desired) {
+		change.operation = planUnchanged
+		return change, nil
+	} else {
+		change.operation = planUpdate
+	}
+	change.diff, err = diffObjects(live, desired)
+	return change, err
+}
+
+// The deletion of an object the reconcile cycle removes, nil if the object doesn't exist or the CR
+// doesn't control it
+func planDeletion(kt *kubeturbo, obj client.Object) (*plannedChange, error) {
+	gvk, err := apiutil.GVKForObject(obj, kt.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if err := kt.Get(obj); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, kt.Cr) {
+		return nil, nil
+	}
+	change := &plannedChange{kind: gvk.Kind, name: obj.GetName(), operation: planDelete}
+	change.diff, err = diffObjects(obj, nil)
+	return change, err
+}
+
+func diffObjects(live, desired client.Object) (string, error) {
+	liveYAML, err := objectYAML(live)
+	if err != nil {
+		return "", err
+	}
+	desiredYAML, err := objectYAML(desired)
+	if err != nil {
+		return "", err
+	}
+	// cmp elides a string compared with an empty one, so a created or deleted object is listed line by line
+	switch {
+	case live == nil:
+		return prefixLines("+ ", desiredYAML), nil
+	case desired == nil:
+		return prefixLines("- ", liveYAML), nil
+	}
+	return cmp.Diff(liveYAML, desiredYAML), nil
+}
+
+func prefixLines(prefix, text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func objectYAML(obj client.Object) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	obj = obj.DeepCopyObject().(client.Object)
+	// bookkeeping of the API server, never changed by the operator
+	obj.SetManagedFields(nil)
+	out, err := yaml.Marshal(obj)
+	return string(out), err
+}
+
+func (kt *kubeturbo) planConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprint("turbo-plan", "-", kt.Name()), Namespace: kt.Namespace()}}
+}
+
+// Writes a summary line per object and the diff of every changed object to the plan ConfigMap
+func (kt *kubeturbo) publishPlan(changes []plannedChange) error {
+	data := map[string]string{}
+	summary := make([]string, 0, len(changes))
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s: %s", change.kind, change.name, change.operation)
+		if change.note != "" {
+			line += " (" + change.note + ")"
+		}
+		summary = append(summary, line)
+		if change.diff != "" {
+			data[change.kind+"."+change.name] = change.diff
+		}
+	}
+	data[planSummaryKey] = strings.Join(summary, "\n")
+	kt.logger.Info("Planned changes: " + strings.Join(summary, ", "))
+
+	cm := kt.planConfigMap()
+	kt.SetControllerReference(cm)
+	_, err := kt.CreateOrUpdate(cm, func() error {
+		cm.ObjectMeta.Labels = kt.labels()
+		cm.Data = data
+		return nil
+	})
+	return err
+}
+
+// Drops the plan once the CR is reconciled, it doesn't describe the live objects anymore
+func (kt *kubeturbo) deletePlan() error {
+	cm := kt.planConfigMap()
+	if err := kt.Get(cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return kt.DeleteIfExists(cm)
+}
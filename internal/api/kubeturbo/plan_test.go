@@ -0,0 +1,143 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Plan", func() {
+	spec := newTestSpec()
+
+	It("Publishes the changes without applying them", func() {
+		staleConfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "turbo-config-kubeturbo-release", Namespace: "turbo"},
+			Data:       map[string]string{"turbo.config": "{}"},
+		}
+		kt := newTestKubeturbo(spec, staleConfig)
+
+		Expect(Plan(kt.Context, kt.Client, kt.APIReader, kt.Scheme, kt.Recorder, kt.Cr)).To(Succeed())
+
+		plan := kt.planConfigMap()
+		Expect(kt.Get(plan)).To(Succeed())
+		Expect(plan.Data[planSummaryKey]).To(ContainSubstring("ConfigMap turbo-config-kubeturbo-release: update"))
+		Expect(plan.Data[planSummaryKey]).To(ContainSubstring("ClusterRoleBinding turbo-all-binding-kubeturbo-release-turbo: create"))
+		Expect(plan.Data[planSummaryKey]).To(ContainSubstring("Deployment kubeturbo-release: create"))
+		Expect(plan.Data["ConfigMap.turbo-config-kubeturbo-release"]).To(ContainSubstring("turbo.example.com"))
+
+		Expect(kt.Get(staleConfig)).To(Succeed())
+		Expect(staleConfig.Data["turbo.config"]).To(Equal("{}"))
+		Expect(errors.IsNotFound(kt.Get(kt.deployment()))).To(BeTrue())
+	})
+
+	It("Reports unchanged objects without a diff", func() {
+		kt := newTestKubeturbo(spec)
+		cm := kt.configMap()
+		Expect(kt.mutateConfigMap(cm)).To(Succeed())
+		Expect(kt.Client.Create(kt.Context, cm)).To(Succeed())
+
+		change, err := planObject(kt, kt.configMap(), mutateAs(kt.mutateConfigMap))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change.operation).To(Equal(planUnchanged))
+		Expect(change.diff).To(BeEmpty())
+	})
+
+	It("Plans every object of the reconcile cycle", func() {
+		kt := newTestKubeturbo(spec)
+		kt.Client.RESTMapper().(*meta.DefaultRESTMapper).Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+		kt.spec.Metrics.Enabled = utils.AsPtr(true)
+		kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA = utils.AsPtr(true)
+
+		changes, err := kt.plan()
+		Expect(err).NotTo(HaveOccurred())
+		var summary []string
+		for _, change := range changes {
+			summary = append(summary, change.kind+" "+change.name+": "+change.operation)
+		}
+		Expect(summary).To(Equal([]string{
+			"ConfigMap turbo-config-kubeturbo-release: create",
+			"ConfigMap trusted-ca-kubeturbo-release: create",
+			"ServiceAccount turbo-user: create",
+			"ClusterRoleBinding turbo-all-binding-kubeturbo-release-turbo: create",
+			"Deployment kubeturbo-release: create",
+			"Service kubeturbo-release-metrics: create",
+			"ServiceMonitor kubeturbo-release-metrics: create",
+		}))
+	})
+
+	It("Plans the deletion of the metrics Service once the metrics are disabled", func() {
+		kt := newTestKubeturbo(spec)
+		kt.spec.Metrics.Enabled = utils.AsPtr(true)
+		Expect(kt.reconcileStep("createOrUpdateMetricsService")).To(Succeed())
+
+		kt.spec.Metrics.Enabled = utils.AsPtr(false)
+		changes, err := kt.plan()
+		Expect(err).NotTo(HaveOccurred())
+		svc := changes[len(changes)-1]
+		Expect(svc.kind + " " + svc.name + ": " + svc.operation).To(Equal("Service kubeturbo-release-metrics: delete"))
+		Expect(svc.diff).To(ContainSubstring("-   name: " + kt.metricsServiceName()))
+	})
+
+	It("Plans the deletion of the trusted CA ConfigMap once the injection is turned off", func() {
+		kt := newTestKubeturbo(spec)
+		kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA = utils.AsPtr(true)
+		Expect(kt.reconcileStep("createOrUpdateTrustedCAConfigMap")).To(Succeed())
+
+		kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA = nil
+		changes, err := kt.plan()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes[1].kind + " " + changes[1].name + ": " + changes[1].operation).To(Equal("ConfigMap trusted-ca-kubeturbo-release: delete"))
+	})
+
+	It("Plans the objects Render renders", func() {
+		renderedSpec := newTestSpec()
+		renderedSpec.Metrics.Enabled = utils.AsPtr(true)
+		renderedSpec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA = utils.AsPtr(true)
+		kt := newTestKubeturbo(renderedSpec)
+		kt.Client.RESTMapper().(*meta.DefaultRESTMapper).Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+
+		objs, err := Render(kt.Context, kt.Client, kt.Scheme, kt.Cr)
+		Expect(err).NotTo(HaveOccurred())
+		var rendered []string
+		for _, obj := range objs {
+			rendered = append(rendered, obj.GetObjectKind().GroupVersionKind().Kind+" "+obj.GetName())
+		}
+		changes, err := kt.plan()
+		Expect(err).NotTo(HaveOccurred())
+		var planned []string
+		for _, change := range changes {
+			planned = append(planned, change.kind+" "+change.name)
+		}
+		Expect(planned).To(Equal(rendered))
+	})
+
+	It("Holds back the changes waiting for the maintenance window", func() {
+		kt := newTestKubeturbo(spec)
+		Expect(kt.reconcileStep("createOrUpdateConfigMap")).To(Succeed())
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+
+		// open for a minute a year
+		kt.spec.MaintenanceWindow = &kubeturbosv1.KubeturboMaintenanceWindow{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}}
+		kt.spec.Image.Tag = utils.AsPtr("8.15.0")
+		kt.spec.TargetConfig.TargetName = utils.AsPtr("changed")
+		changes, err := kt.plan()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes[0].kind).To(Equal("ConfigMap"))
+		Expect(changes[0].diff).NotTo(ContainSubstring("changed"))
+		dep := changes[len(changes)-1]
+		Expect(dep.kind).To(Equal("Deployment"))
+		Expect(dep.diff).NotTo(ContainSubstring("8.15.0"))
+		Expect(dep.note).To(ContainSubstring("in the maintenance window opening at"))
+		Expect(dep.note).To(ContainSubstring("kubeturbo:8.15.0 held back until the maintenance window"))
+	})
+})
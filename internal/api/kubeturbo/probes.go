@@ -0,0 +1,75 @@
+package kubeturbo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+)
+
+const (
+	probePeriodSeconds            = 10
+	probeTimeoutSeconds           = 5
+	probeFailureThreshold         = 3
+	livenessPeriodSeconds         = 30
+	defaultRegistrationTimeoutSec = 300
+)
+
+func (kt *kubeturbo) probesEnabled() bool {
+	return kt.spec.Probes.Enabled == nil || *kt.spec.Probes.Enabled
+}
+
+// Probe against the health endpoint served by the kubeturbo container
+func healthProbe(periodSeconds, failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   constants.KubeturboHealthPath,
+				Port:   intstr.FromInt(constants.KubeturboPort),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		PeriodSeconds:    periodSeconds,
+		TimeoutSeconds:   probeTimeoutSeconds,
+		SuccessThreshold: 1,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+func (kt *kubeturbo) livenessProbe() *corev1.Probe {
+	if !kt.probesEnabled() {
+		return nil
+	}
+	if kt.spec.Probes.LivenessProbe != nil {
+		return kt.spec.Probes.LivenessProbe
+	}
+	return healthProbe(livenessPeriodSeconds, probeFailureThreshold)
+}
+
+func (kt *kubeturbo) readinessProbe() *corev1.Probe {
+	if !kt.probesEnabled() {
+		return nil
+	}
+	if kt.spec.Probes.ReadinessProbe != nil {
+		return kt.spec.Probes.ReadinessProbe
+	}
+	return healthProbe(probePeriodSeconds, probeFailureThreshold)
+}
+
+// The startup probe holds off the liveness probe until kubeturbo had the chance to
+// register with the Turbo server, so a slow registration doesn't get the pod killed
+func (kt *kubeturbo) startupProbe() *corev1.Probe {
+	if !kt.probesEnabled() {
+		return nil
+	}
+	if kt.spec.Probes.StartupProbe != nil {
+		return kt.spec.Probes.StartupProbe
+	}
+	timeoutSec := defaultRegistrationTimeoutSec
+	if kt.spec.SdkProtocolConfig.RegistrationTimeoutSec != nil && *kt.spec.SdkProtocolConfig.RegistrationTimeoutSec > 0 {
+		timeoutSec = *kt.spec.SdkProtocolConfig.RegistrationTimeoutSec
+	}
+	// round up so the probe never gives up before the registration times out
+	failureThreshold := (timeoutSec+probePeriodSeconds-1)/probePeriodSeconds + 1
+	return healthProbe(probePeriodSeconds, int32(failureThreshold))
+}
@@ -0,0 +1,64 @@
+package kubeturbo
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Probes", func() {
+	It("Probes the health endpoint on the port passed to kubeturbo", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{})
+
+		for _, probe := range []*corev1.Probe{kt.livenessProbe(), kt.readinessProbe(), kt.startupProbe()} {
+			Expect(probe.HTTPGet.Path).To(Equal(constants.KubeturboHealthPath))
+			Expect(probe.HTTPGet.Port).To(Equal(intstr.FromInt(constants.KubeturboPort)))
+		}
+		Expect(kt.containerArgs()).To(ContainElement("--port=10265"))
+		Expect(kt.livenessProbe().PeriodSeconds).To(BeEquivalentTo(30))
+		Expect(kt.readinessProbe().PeriodSeconds).To(BeEquivalentTo(10))
+	})
+
+	DescribeTable("Sizes the startup probe to give up a period after the registration timeout",
+		func(timeoutSec *int, failureThreshold int) {
+			kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+				SdkProtocolConfig: kubeturbosv1.KubeturboSdkProtocolConfig{RegistrationTimeoutSec: timeoutSec},
+			})
+
+			probe := kt.startupProbe()
+			Expect(probe.PeriodSeconds).To(BeEquivalentTo(10))
+			Expect(probe.FailureThreshold).To(BeEquivalentTo(failureThreshold))
+		},
+		Entry("the default timeout", nil, 31),
+		Entry("a multiple of the period", utils.AsPtr(120), 13),
+		Entry("a timeout between two periods", utils.AsPtr(125), 14),
+		Entry("a timeout shorter than the period", utils.AsPtr(1), 2),
+		Entry("a timeout that isn't positive", utils.AsPtr(0), 31),
+	)
+
+	It("Uses the probes set in the spec", func() {
+		custom := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"true"}}}}
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			Probes: kubeturbosv1.KubeturboProbes{LivenessProbe: custom, ReadinessProbe: custom, StartupProbe: custom},
+		})
+
+		Expect(kt.livenessProbe()).To(BeIdenticalTo(custom))
+		Expect(kt.readinessProbe()).To(BeIdenticalTo(custom))
+		Expect(kt.startupProbe()).To(BeIdenticalTo(custom))
+	})
+
+	It("Sets no probes when disabled", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			Probes: kubeturbosv1.KubeturboProbes{Enabled: utils.AsPtr(false)},
+		})
+
+		Expect(kt.livenessProbe()).To(BeNil())
+		Expect(kt.readinessProbe()).To(BeNil())
+		Expect(kt.startupProbe()).To(BeNil())
+	})
+})
@@ -0,0 +1,256 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+const (
+	proxyUsernameEnvVar = "KUBETURBO_PROXY_USERNAME"
+	proxyPasswordEnvVar = "KUBETURBO_PROXY_PASSWORD"
+	proxyUsernameKey    = "username"
+	proxyPasswordKey    = "password"
+)
+
+// In-cluster destinations that never go through the proxy. KUBERNETES_SERVICE_HOST
+// is expanded by the kubelet to the address kubeturbo uses to reach the API server
+var inClusterNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local", "$(KUBERNETES_SERVICE_HOST)"}
+
+func (kt *kubeturbo) proxyURL() string {
+	if kt.spec.ServerMeta.ProxyConfig.URL != nil {
+		return *kt.spec.ServerMeta.ProxyConfig.URL
+	}
+	if kt.spec.ServerMeta.Proxy != nil {
+		return *kt.spec.ServerMeta.Proxy
+	}
+	return ""
+}
+
+// The proxy written to turbo.config. Proxies that need credentials from a secret are
+// only handed to kubeturbo through the environment
+func (kt *kubeturbo) turboConfigProxy() string {
+	if kt.spec.ServerMeta.ProxyConfig.SecretRef != nil {
+		return ""
+	}
+	return kt.proxyURL()
+}
+
+func (kt *kubeturbo) injectProxyEnv() bool {
+	proxyConfig := kt.spec.ServerMeta.ProxyConfig
+	return kt.proxyURL() != "" && (proxyConfig.SecretRef != nil || (proxyConfig.InjectEnv != nil && *proxyConfig.InjectEnv))
+}
+
+func (kt *kubeturbo) proxyEnv() ([]corev1.EnvVar, error) {
+	if !kt.injectProxyEnv() {
+		return nil, nil
+	}
+
+	proxyConfig := kt.spec.ServerMeta.ProxyConfig
+	env := []corev1.EnvVar{}
+	proxy := kt.proxyURL()
+	if proxyConfig.SecretRef != nil {
+		scheme, host, found := strings.Cut(proxy, "://")
+		if !found {
+			return nil, fmt.Errorf("serverMeta.proxyConfig.url %s must start with a scheme such as http://", proxy)
+		}
+		env = append(env,
+			secretEnvVar(proxyUsernameEnvVar, proxyConfig.SecretRef.Name, proxyUsernameKey),
+			secretEnvVar(proxyPasswordEnvVar, proxyConfig.SecretRef.Name, proxyPasswordKey),
+		)
+		// the kubelet expands the references to the variables defined above
+		proxy = fmt.Sprintf("%s://$(%s):$(%s)@%s", scheme, proxyUsernameEnvVar, proxyPasswordEnvVar, host)
+	}
+
+	env = append(env,
+		corev1.EnvVar{Name: "HTTPS_PROXY", Value: proxy},
+		corev1.EnvVar{Name: "HTTP_PROXY", Value: proxy},
+	)
+	if noProxy := kt.noProxy(); len(noProxy) > 0 {
+		env = append(env, corev1.EnvVar{Name: "NO_PROXY", Value: strings.Join(noProxy, ",")})
+	}
+	return env, nil
+}
+
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+func (kt *kubeturbo) noProxy() []string {
+	noProxy := []string{}
+	appendUnique := func(entries ...string) {
+		for _, entry := range entries {
+			if entry = strings.TrimSpace(entry); entry != "" && !utils.StringInSlice(entry, noProxy) {
+				noProxy = append(noProxy, entry)
+			}
+		}
+	}
+
+	appendUnique(kt.spec.ServerMeta.ProxyConfig.NoProxy...)
+	autoNoProxy := kt.spec.ServerMeta.ProxyConfig.AutoNoProxy
+	if autoNoProxy == nil || *autoNoProxy {
+		appendUnique(inClusterNoProxy...)
+		appendUnique(kt.clusterNetworks()...)
+	}
+	return noProxy
+}
+
+// How long the networks of the cluster are cached, they hardly ever change
+const clusterNetworksTTL = time.Hour
+
+// The networks of the cluster the operator runs in
+var clusterNetworksCache struct {
+	sync.Mutex
+	cidrs    []string
+	lookedUp time.Time
+}
+
+// The service, pod and node networks of the cluster, looked up at most once per TTL since every
+// reconcile, plan and render builds the Deployment. A failed lookup isn't cached, the next one retries
+func (kt *kubeturbo) clusterNetworks() []string {
+	clusterNetworksCache.Lock()
+	defer clusterNetworksCache.Unlock()
+	if time.Since(clusterNetworksCache.lookedUp) < clusterNetworksTTL {
+		return clusterNetworksCache.cidrs
+	}
+	cidrs, err := kt.lookupClusterNetworks()
+	if err != nil {
+		kt.logger.Error(err, "Unable to look up the networks of the cluster, NO_PROXY may be incomplete")
+		return cidrs
+	}
+	clusterNetworksCache.cidrs = cidrs
+	clusterNetworksCache.lookedUp = time.Now()
+	return cidrs
+}
+
+// Best effort lookup of the networks of the cluster: the service network, the pod network and the node
+// network, which kubeturbo reaches the kubelets on. Returns what was found along with the failed lookups
+func (kt *kubeturbo) lookupClusterNetworks() ([]string, error) {
+	var services, pods, nodes []string
+	var errs []error
+	// not found errors only mean that the cluster isn't of that kind
+	found := func(err error) bool {
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			errs = append(errs, err)
+		}
+		return err == nil
+	}
+
+	// OpenShift publishes the service and pod networks in the cluster network config
+	network := &unstructured.Unstructured{}
+	network.SetGroupVersionKind(schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Network"})
+	if found(kt.APIReader.Get(kt.Context, client.ObjectKey{Name: "cluster"}, network)) {
+		services, _, _ = unstructured.NestedStringSlice(network.Object, "status", "serviceNetwork")
+		clusterNetworks, _, _ := unstructured.NestedSlice(network.Object, "status", "clusterNetwork")
+		for _, clusterNetwork := range clusterNetworks {
+			if entry, ok := clusterNetwork.(map[string]interface{}); ok {
+				if cidr, _, _ := unstructured.NestedString(entry, "cidr"); cidr != "" {
+					pods = append(pods, cidr)
+				}
+			}
+		}
+	}
+	// and the node network in the install config
+	installConfig := &corev1.ConfigMap{}
+	if found(kt.APIReader.Get(kt.Context, client.ObjectKey{Namespace: "kube-system", Name: "cluster-config-v1"}, installConfig)) {
+		var config struct {
+			Networking struct {
+				MachineNetwork []struct {
+					CIDR string `json:"cidr"`
+				} `json:"machineNetwork"`
+			} `json:"networking"`
+		}
+		if err := yaml.Unmarshal([]byte(installConfig.Data["install-config"]), &config); err != nil {
+			errs = append(errs, fmt.Errorf("invalid install-config in ConfigMap %s: %w", installConfig.Name, err))
+		}
+		for _, machineNetwork := range config.Networking.MachineNetwork {
+			nodes = append(nodes, machineNetwork.CIDR)
+		}
+	}
+
+	// kubeadm keeps the service and pod networks in its cluster configuration
+	kubeadmConfig := &corev1.ConfigMap{}
+	if len(services) == 0 && found(kt.APIReader.Get(kt.Context, client.ObjectKey{Namespace: "kube-system", Name: "kubeadm-config"}, kubeadmConfig)) {
+		var config struct {
+			Networking struct {
+				ServiceSubnet string `json:"serviceSubnet"`
+				PodSubnet     string `json:"podSubnet"`
+			} `json:"networking"`
+		}
+		if err := yaml.Unmarshal([]byte(kubeadmConfig.Data["ClusterConfiguration"]), &config); err != nil {
+			errs = append(errs, fmt.Errorf("invalid ClusterConfiguration in ConfigMap %s: %w", kubeadmConfig.Name, err))
+		}
+		services = splitCIDRs(config.Networking.ServiceSubnet)
+		pods = splitCIDRs(config.Networking.PodSubnet)
+	}
+
+	// otherwise the flags of the control plane tell
+	if len(services) == 0 {
+		cidrs, err := kt.controlPlaneFlag("kube-apiserver", "--service-cluster-ip-range")
+		found(err)
+		services = cidrs
+	}
+	if len(pods) == 0 {
+		cidrs, err := kt.controlPlaneFlag("kube-controller-manager", "--cluster-cidr")
+		found(err)
+		pods = cidrs
+	}
+
+	// or settle for the cluster IP of the API server service
+	if len(services) == 0 {
+		svc := &corev1.Service{}
+		if found(kt.APIReader.Get(kt.Context, client.ObjectKey{Namespace: "default", Name: "kubernetes"}, svc)) && svc.Spec.ClusterIP != "" {
+			kt.logger.Info("Unable to determine the service network of the cluster, add it to serverMeta.proxyConfig.noProxy if needed")
+			services = []string{svc.Spec.ClusterIP}
+		}
+	}
+	if len(nodes) == 0 {
+		kt.logger.Info("Unable to determine the node network of the cluster, add it to serverMeta.proxyConfig.noProxy so that kubeturbo reaches the kubelets without the proxy")
+	}
+	return append(append(services, pods...), nodes...), utilerrors.NewAggregate(errs)
+}
+
+// The CIDRs a control plane component is started with, e.g. --cluster-cidr=10.244.0.0/16
+func (kt *kubeturbo) controlPlaneFlag(component, flag string) ([]string, error) {
+	var pods corev1.PodList
+	if err := kt.APIReader.List(kt.Context, &pods, client.InNamespace("kube-system"), client.MatchingLabels{"component": component}); err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			for _, arg := range append(container.Command, container.Args...) {
+				if cidrs, found := strings.CutPrefix(arg, flag+"="); found {
+					return splitCIDRs(cidrs), nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func splitCIDRs(cidrs string) []string {
+	if cidrs == "" {
+		return nil
+	}
+	return strings.Split(cidrs, ",")
+}
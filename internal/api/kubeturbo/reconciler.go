@@ -11,6 +11,8 @@ import (
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -18,10 +20,12 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/metrics"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
 )
 
@@ -33,43 +37,174 @@ type kubeturbo struct {
 	*KubeturboRequest
 	spec   kubeturbosv1.KubeturboSpec
 	logger logr.Logger
+	// UID of the kube-system namespace, looked up on demand
+	clusterID types.UID
+	// disruptive changes waiting for the maintenance window
+	pendingChanges  []string
+	restartHeldBack bool
+	// kubeturbo is bound to the reader role for the action freeze
+	actionsFrozen bool
+	// when the CR must be reconciled again for a scheduled change, and why
+	requeue       time.Time
+	requeueReason string
 }
 
 type block = utils.Block
 
-func Reconcile(ctx context.Context, client client.Client, scheme *runtime.Scheme, ktV1 *kubeturbosv1.Kubeturbo) error {
+func Reconcile(ctx context.Context, client client.Client, apiReader client.Reader, scheme *runtime.Scheme, recorder record.EventRecorder, ktV1 *kubeturbosv1.Kubeturbo) error {
 	logger := log.FromContext(ctx).WithName("TearUp-cycle")
-	kr := NewKubeturboRequest(client, ctx, scheme, ktV1)
+	kr := NewKubeturboRequest(client, apiReader, ctx, scheme, recorder, ktV1)
 	kt := kubeturbo{KubeturboRequest: kr, spec: kr.Cr.Spec, logger: logger}
 	return kt.reconcileKubeTurbo()
 }
 
-func Teardown(ctx context.Context, client client.Client, scheme *runtime.Scheme, ktV1 *kubeturbosv1.Kubeturbo) error {
+func Teardown(ctx context.Context, client client.Client, apiReader client.Reader, scheme *runtime.Scheme, recorder record.EventRecorder, ktV1 *kubeturbosv1.Kubeturbo) error {
 	logger := log.FromContext(ctx).WithName("TearDown-cycle")
-	kr := NewKubeturboRequest(client, ctx, scheme, ktV1)
+	kr := NewKubeturboRequest(client, apiReader, ctx, scheme, recorder, ktV1)
 	kt := kubeturbo{KubeturboRequest: kr, spec: kr.Cr.Spec, logger: logger}
+	forgetServerProbe(ktV1)
 	return kt.cleanUpClusterResources()
 }
 
 func (kt *kubeturbo) reconcileKubeTurbo() error {
-	return utils.ReturnOnError(
-		kt.createOrUpdateConfigMap,
-		kt.createOrUpdateServiceAccount,
-		kt.createOrUpdateClusterRole,
-		kt.createOrUpdateClusterRoleBinding,
-		kt.createOrUpdateDeployment,
-		kt.updateClusterResource,
-	)
+	// each step reports its latency and errors under its name and is traced as a span
+	step := func(name string, fn utils.ErrorFn) utils.ErrorFn {
+		return metrics.Step(name, kt.Traced(name, fn))
+	}
+	if err := utils.ReturnOnError(
+		step("resolveTargetName", kt.resolveTargetName),
+		step("checkConflicts", kt.checkConflicts),
+		step("adoptLegacyKubeturbo", kt.adoptLegacyKubeturbo),
+		step("checkServerConnectivity", kt.checkServerConnectivity),
+		step("resolveServerVersion", kt.resolveServerVersion),
+		step("validateTrustedCA", kt.validateTrustedCA),
+		step("updateActionFreeze", kt.updateActionFreeze),
+	); err != nil {
+		return err
+	}
+
+	children, err := kt.childObjects()
+	if err != nil {
+		return err
+	}
+	steps := make([]utils.ErrorFn, 0, len(children)+3)
+	for _, child := range children {
+		child := child
+		steps = append(steps, step(child.step, func() error { return kt.reconcileChildObject(child) }))
+	}
+	return utils.ReturnOnError(append(steps,
+		step("updateClusterResource", kt.updateClusterResource),
+		step("deletePlan", kt.deletePlan),
+		step("awaitSchedule", kt.awaitSchedule),
+	)...)
+}
+
+// An object the operator manages for the CR
+type childObject struct {
+	// the reconcile step creating or updating the object
+	step string
+	obj  client.Object
+	// a disabled object is deleted if the CR controls it
+	enabled bool
+	// cluster-level objects can't be owned by the CR
+	owned bool
+	// whether the cluster serves the kind of the object at all, nil if it always does
+	available func() (bool, error)
+	// applied to the live object, or to obj if there is none
+	mutate func(client.Object) error
+	// run by the reconcile cycle around the update of the object
+	beforeApply, afterApply func(obj client.Object) error
+}
+
+// Adapts a typed mutate function to the objects of the childObject table
+func mutateAs[T client.Object](mutate func(T) error) func(client.Object) error {
+	return func(obj client.Object) error {
+		return mutate(obj.(T))
+	}
+}
+
+// The objects of the CR in the order the reconcile cycle creates them. The reconcile cycle, the plan
+// and Render all build the objects from this table. Outside the maintenance window the changes that
+// restart kubeturbo are held back
+func (kt *kubeturbo) childObjects() ([]childObject, error) {
+	inWindow, nextWindow, err := kt.maintenanceWindow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return []childObject{
+		{step: "createOrUpdateConfigMap", obj: kt.configMap(), enabled: true, owned: true,
+			mutate: mutateAs(func(cm *corev1.ConfigMap) error { return kt.mutateConfigMapInWindow(cm, inWindow) })},
+		{step: "createOrUpdateTrustedCAConfigMap", obj: kt.trustedCAConfigMap(), enabled: kt.injectOpenShiftTrustedCA(), owned: true,
+			mutate: mutateAs(kt.mutateTrustedCAConfigMap)},
+		{step: "createOrUpdateServiceAccount", obj: kt.serviceAccount(), enabled: true, owned: true,
+			mutate: mutateAs(func(sa *corev1.ServiceAccount) error { return kt.mutateServiceAccount(sa, true) })},
+		// if roleName is cluster-admin or any custom names other than "turbo-cluster-admin" or "turbo-cluster-reader", don't override it
+		{step: "createOrUpdateClusterRole", obj: kt.clusterRole(), enabled: kt.generatesClusterRole(), owned: false,
+			mutate: mutateAs(kt.mutateClusterRole)},
+		{step: "createOrUpdateClusterRoleBinding", obj: kt.clusterRoleBinding(), enabled: true, owned: false,
+			mutate: mutateAs(kt.mutateClusterRoleBinding)},
+		{step: "createOrUpdateDeployment", obj: kt.deployment(), enabled: true, owned: true,
+			mutate:      mutateAs(func(dep *appsv1.Deployment) error { return kt.mutateDeploymentInWindow(dep, inWindow) }),
+			beforeApply: func(client.Object) error { return kt.restartForConfigChange(inWindow) },
+			afterApply: func(obj client.Object) error {
+				return kt.deploymentApplied(obj.(*appsv1.Deployment), nextWindow)
+			}},
+		{step: "createOrUpdateMetricsService", obj: kt.metricsService(), enabled: kt.metricsEnabled(), owned: true,
+			mutate: mutateAs(kt.mutateMetricsService)},
+		{step: "createOrUpdateServiceMonitor", obj: kt.serviceMonitor(), enabled: kt.metricsEnabled(), owned: true,
+			available: kt.serviceMonitorAvailable, mutate: mutateAs(kt.mutateServiceMonitor)},
+	}, nil
+}
+
+// Creates or updates an enabled object, and deletes a disabled one the CR controls, e.g. once the
+// metrics are turned off
+func (kt *kubeturbo) reconcileChildObject(child childObject) error {
+	if child.available != nil {
+		if available, err := child.available(); err != nil || !available {
+			return err
+		}
+	}
+	obj := child.obj
+	if !child.enabled {
+		if !child.owned {
+			return nil
+		}
+		// the lookup is served by the cache
+		if err := kt.Get(obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if metav1.IsControlledBy(obj, kt.Cr) {
+			return kt.DeleteIfExists(obj)
+		}
+		return nil
+	}
+
+	if child.beforeApply != nil {
+		if err := child.beforeApply(obj); err != nil {
+			return err
+		}
+	}
+	if child.owned {
+		kt.SetControllerReference(obj)
+	}
+	if _, err := kt.CreateOrUpdate(obj, func() error {
+		return child.mutate(obj)
+	}); err != nil {
+		return err
+	}
+	if child.afterApply != nil {
+		return child.afterApply(obj)
+	}
+	return nil
 }
 
 func (kt *kubeturbo) deployment() *appsv1.Deployment {
 	return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: kt.Name(), Namespace: kt.Namespace()}}
 }
 
-func (kt *kubeturbo) createOrUpdateDeployment() error {
-	dep := kt.deployment()
-	kt.SetControllerReference(dep)
-
+// Restarts kubeturbo to pick up a changed turbo.config, by deleting the Deployment for the next
+// cycle to create it again. The restart waits for the changes to settle and for the maintenance window
+func (kt *kubeturbo) restartForConfigChange(inWindow bool) error {
 	// The Kubeturbo pod need to restart to loop in config updates
 	oldConfigMapHash := kt.Cr.Status.ConfigHash
 	newConfigMapHash, hashErr := kt.getKubeturboConfigHash()
@@ -81,20 +216,84 @@ func (kt *kubeturbo) createOrUpdateDeployment() error {
 	// the second reconcile cycle arrived between the deployment get deleted
 	// and CR status updates
 	if oldConfigMapHash != "" && oldConfigMapHash != newConfigMapHash {
-		// update CR hash to prevent infinity loop
-		kt.updateClusterResource()
+		settled, err := kt.settleRestart(newConfigMapHash)
+		if err != nil {
+			return err
+		}
 
-		kt.logger.Info("Kubeturbo deploy needs to restart to pick up changes")
-		if err := kt.DeleteIfExists(kt.deployment()); err != nil {
+		switch {
+		case !settled:
+			// only the restart waits, the other changes are applied meanwhile
+			kt.restartHeldBack = true
+		case !inWindow:
+			kt.restartHeldBack = true
+			kt.pendingChanges = append(kt.pendingChanges, "restart of kubeturbo to pick up the turbo.config changes")
+		default:
+			// update CR hash to prevent infinity loop
+			kt.Cr.Status.PendingRestart = nil
+			// restarting without the new hash in the status would restart kubeturbo again
+			if err := kt.updateClusterResource(); err != nil {
+				return err
+			}
+
+			kt.logger.Info("Kubeturbo deploy needs to restart to pick up changes")
+			kt.Event(corev1.EventTypeNormal, "Restarting", "Restarting kubeturbo to pick up the configuration change")
+			if err := kt.DeleteIfExists(kt.deployment()); err != nil {
+				return err
+			}
+			metrics.ConfigRestarts.WithLabelValues(kt.Namespace(), kt.Name()).Inc()
+			return constants.ErrRequeueOnDeletion
+		}
+	} else if kt.Cr.Status.PendingRestart != nil {
+		// the changes got reverted before kubeturbo restarted
+		kt.Cr.Status.PendingRestart = nil
+		if err := kt.UpdateStatus(); err != nil {
 			return err
 		}
-		return constants.ErrRequeueOnDeletion
+	}
+	return nil
+}
+
+// Reports the version kubeturbo runs with and the changes held back for the maintenance window
+func (kt *kubeturbo) deploymentApplied(dep *appsv1.Deployment, nextWindow time.Time) error {
+	if kt.spec.Image.Tag != nil && kubeturboImage(dep) == kt.image() {
+		metrics.SetKubeturboVersion(kt.Cr, *kt.spec.Image.Tag)
+	}
+	return kt.updateMaintenanceStatus(nextWindow)
+}
+
+// Holds back the restart until the turbo.config stopped changing for the settle window, so the changes
+// made within the window are picked up by a single restart. The pending restart is kept in the CR status
+// and the CR is reconciled again when the window passes
+func (kt *kubeturbo) settleRestart(configHash string) (bool, error) {
+	if kt.spec.RestartSettleWindow == nil || kt.spec.RestartSettleWindow.Duration <= 0 {
+		return true, nil
+	}
+
+	now := time.Now()
+	pending := kt.Cr.Status.PendingRestart
+	if pending == nil || pending.ConfigHash != configHash {
+		// every change starts the window over
+		since := metav1.NewTime(now)
+		if pending != nil {
+			since = pending.Since
+		}
+		kt.Cr.Status.PendingRestart = &kubeturbosv1.KubeturboPendingRestart{
+			ConfigHash:   configHash,
+			Since:        since,
+			RestartAfter: metav1.NewTime(now.Add(kt.spec.RestartSettleWindow.Duration)),
+		}
+		if err := kt.UpdateStatus(); err != nil {
+			return false, err
+		}
+		kt.logger.Info("Kubeturbo restarts once the configuration changes settled", "restartAfter", kt.Cr.Status.PendingRestart.RestartAfter)
 	}
 
-	_, err := kt.CreateOrUpdate(dep, func() error {
-		return kt.mutateDeployment(dep)
-	})
-	return err
+	if restartAfter := kt.Cr.Status.PendingRestart.RestartAfter.Time; restartAfter.After(now) {
+		kt.requeueAt(restartAfter, "kubeturbo restart is waiting for the configuration changes to settle")
+		return false, nil
+	}
+	return true, nil
 }
 
 func (kt *kubeturbo) mutateDeployment(dep *appsv1.Deployment) error {
@@ -129,6 +328,11 @@ func (kt *kubeturbo) mutateDeployment(dep *appsv1.Deployment) error {
 		resourceRequirements = kt.spec.Resources.Internalize()
 	}
 
+	env, err := kt.containerEnv()
+	if err != nil {
+		return err
+	}
+
 	dep.Spec = appsv1.DeploymentSpec{
 		Replicas: kt.spec.ReplicaCount,
 		Strategy: appsv1.DeploymentStrategy{
@@ -154,18 +358,9 @@ func (kt *kubeturbo) mutateDeployment(dep *appsv1.Deployment) error {
 				},
 				Containers: []corev1.Container{
 					{
-						Name: constants.KubeturboContainerName,
-						Env: []corev1.EnvVar{
-							{
-								Name: "KUBETURBO_NAMESPACE",
-								ValueFrom: &corev1.EnvVarSource{
-									FieldRef: &corev1.ObjectFieldSelector{
-										FieldPath: "metadata.namespace",
-									},
-								},
-							},
-						},
-						Image:           fmt.Sprint(kt.spec.Image.Repository, ":", *kt.spec.Image.Tag),
+						Name:            constants.KubeturboContainerName,
+						Env:             env,
+						Image:           kt.image(),
 						ImagePullPolicy: imagePullPolicy,
 						Args:            kt.containerArgs(),
 						SecurityContext: &corev1.SecurityContext{
@@ -178,66 +373,127 @@ func (kt *kubeturbo) mutateDeployment(dep *appsv1.Deployment) error {
 								},
 							},
 						},
-						Resources: resourceRequirements,
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								Name:      "turbo-volume",
-								MountPath: "/etc/kubeturbo",
-								ReadOnly:  true,
-							},
-							{
-								Name:      "turbonomic-credentials-volume",
-								MountPath: "/etc/turbonomic-credentials",
-								ReadOnly:  true,
-							},
-							{
-								Name:      "varlog",
-								MountPath: "/var/log",
-							},
-						},
-					},
-				},
-				Volumes: []corev1.Volume{
-					{
-						Name: "turbo-volume",
-						VolumeSource: corev1.VolumeSource{
-							ConfigMap: &corev1.ConfigMapVolumeSource{
-								LocalObjectReference: corev1.LocalObjectReference{
-									Name: kt.configMap().Name,
-								},
-							},
-						},
-					},
-					{
-						Name: "turbonomic-credentials-volume",
-						VolumeSource: corev1.VolumeSource{
-							Secret: &corev1.SecretVolumeSource{
-								SecretName:  kt.spec.RestAPIConfig.TurbonomicCredentialsSecretName,
-								Optional:    utils.AsPtr(true),
-								DefaultMode: utils.AsPtr(int32(420)),
-							},
-						},
-					},
-					{
-						Name: "varlog",
-						VolumeSource: corev1.VolumeSource{
-							EmptyDir: &corev1.EmptyDirVolumeSource{},
-						},
+						Resources:      resourceRequirements,
+						LivenessProbe:  kt.livenessProbe(),
+						ReadinessProbe: kt.readinessProbe(),
+						StartupProbe:   kt.startupProbe(),
+						VolumeMounts:   kt.volumeMounts(),
 					},
 				},
+				Volumes: kt.volumes(),
 			},
 		},
 	}
 
+	templateHash, err := podTemplateHash(&dep.Spec.Template)
+	if err != nil {
+		return err
+	}
+	if metadata.Annotations == nil {
+		metadata.Annotations = map[string]string{}
+	}
+	metadata.Annotations[constants.PodTemplateHashAnnotation] = templateHash
+
 	return nil
 }
 
+func (kt *kubeturbo) image() string {
+	return fmt.Sprint(kt.spec.Image.Repository, ":", *kt.spec.Image.Tag)
+}
+
+func (kt *kubeturbo) containerEnv() ([]corev1.EnvVar, error) {
+	env := []corev1.EnvVar{
+		{
+			Name: "KUBETURBO_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.namespace",
+				},
+			},
+		},
+	}
+	if kt.hasTrustedCA() {
+		// Go programs load every certificate file under the SSL_CERT_DIR directories on top of the system
+		// bundle, the default directories are kept so the certificates installed in the image still apply
+		env = append(env, corev1.EnvVar{Name: "SSL_CERT_DIR", Value: trustedCAMountPath + ":" + defaultCertDirs})
+	}
+	proxyEnv, err := kt.proxyEnv()
+	if err != nil {
+		return nil, err
+	}
+	return append(env, proxyEnv...), nil
+}
+
+func (kt *kubeturbo) volumeMounts() []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      "turbo-volume",
+			MountPath: "/etc/kubeturbo",
+			ReadOnly:  true,
+		},
+		{
+			Name:      "turbonomic-credentials-volume",
+			MountPath: "/etc/turbonomic-credentials",
+			ReadOnly:  true,
+		},
+		{
+			Name:      "varlog",
+			MountPath: "/var/log",
+		},
+	}
+	if kt.hasTrustedCA() {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      trustedCAVolumeName,
+			MountPath: trustedCAMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
+
+func (kt *kubeturbo) volumes() []corev1.Volume {
+	volumes := []corev1.Volume{
+		{
+			Name: "turbo-volume",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: kt.configMap().Name,
+					},
+				},
+			},
+		},
+		{
+			Name: "turbonomic-credentials-volume",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  kt.spec.RestAPIConfig.TurbonomicCredentialsSecretName,
+					Optional:    utils.AsPtr(true),
+					DefaultMode: utils.AsPtr(int32(420)),
+				},
+			},
+		},
+		{
+			Name: "varlog",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	if volume := kt.trustedCAVolume(); volume != nil {
+		volumes = append(volumes, *volume)
+	}
+	return volumes
+}
+
 func (kt *kubeturbo) containerArgs() []string {
 	args := make([]string, 0, 25)
 
 	ktArgs := kt.spec.Args
 
 	args = append(args, "--turboconfig=/etc/kubeturbo/turbo.config")
+	// the probes and the metrics Service rely on the port
+	args = append(args, fmt.Sprintf("--port=%d", constants.KubeturboPort))
 	if ktArgs.Logginglevel != nil {
 		args = append(args, fmt.Sprintf("--v=%d", *ktArgs.Logginglevel))
 	}
@@ -328,46 +584,27 @@ func (kt *kubeturbo) configMap() *corev1.ConfigMap {
 	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: kt.configMapName(), Namespace: kt.Namespace()}}
 }
 
-func (kt *kubeturbo) createOrUpdateConfigMap() error {
-	cm := kt.configMap()
-	kt.SetControllerReference(cm)
-	_, err := kt.CreateOrUpdate(cm, func() error {
-		return kt.mutateConfigMap(cm)
-	})
-	return err
-}
-
 func (kt *kubeturbo) mutateConfigMap(cm *corev1.ConfigMap) error {
-	// kubeturbo config
-	cByteString, cError := kt.buildKubeturboConfig()
-	if cError != nil {
-		return cError
-	}
-
-	// dynamic config
-	dcByteString, dcError := kt.buildKubeturboDynamicConfig()
-	if dcError != nil {
-		return dcError
+	data, err := kt.renderConfig()
+	if err != nil {
+		return err
 	}
 
 	labels := kt.labels()
 	cm.ObjectMeta.Labels = labels
-	cm.Data = map[string]string{
-		"turbo.config":            string(cByteString),
-		"turbo-autoreload.config": string(dcByteString),
-	}
+	cm.Data = data
 
 	return nil
 }
 
 func (kt *kubeturbo) getKubeturboConfigHash() (string, error) {
-	cByteString, err := kt.buildKubeturboConfig()
+	data, err := kt.renderConfig()
 	if err != nil {
 		return "", err
 	}
 
 	hash := fnv.New64()
-	if _, err = hash.Write(cByteString); err != nil {
+	if _, err = hash.Write([]byte(data[turboConfigKey])); err != nil {
 		return "", err
 	}
 
@@ -383,8 +620,8 @@ func (kt *kubeturbo) buildKubeturboConfig() ([]byte, error) {
 	if kt.spec.ServerMeta.TurboServer != "" {
 		serverMeta["turboServer"] = kt.spec.ServerMeta.TurboServer
 	}
-	if kt.spec.ServerMeta.Proxy != nil {
-		serverMeta["proxy"] = *kt.spec.ServerMeta.Proxy
+	if proxy := kt.turboConfigProxy(); proxy != "" {
+		serverMeta["proxy"] = proxy
 	}
 
 	commConfig := block{"serverMeta": serverMeta}
@@ -531,15 +768,6 @@ func (kt *kubeturbo) serviceAccount() *corev1.ServiceAccount {
 	return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: kt.serviceAccountName(), Namespace: kt.Namespace()}}
 }
 
-func (kt *kubeturbo) createOrUpdateServiceAccount() error {
-	sa := kt.serviceAccount()
-	kt.SetControllerReference(sa)
-	_, err := kt.CreateOrUpdate(sa, func() error {
-		return kt.mutateServiceAccount(sa, true)
-	})
-	return err
-}
-
 func (kt *kubeturbo) mutateServiceAccount(sa *corev1.ServiceAccount, addFinalizer bool) error {
 	sa.ObjectMeta.Labels = kt.labels()
 	if !addFinalizer {
@@ -552,31 +780,33 @@ func (kt *kubeturbo) mutateServiceAccount(sa *corev1.ServiceAccount, addFinalize
 	return nil
 }
 
+// A Conflicted CR shares the service account of an older CR, which must keep its finalizer
+func (kt *kubeturbo) ownsServiceAccount() (bool, error) {
+	sa := kt.serviceAccount()
+	if err := kt.Get(sa); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	owner := metav1.GetControllerOf(sa)
+	return owner == nil || owner.UID == kt.Cr.UID, nil
+}
+
 func (kt *kubeturbo) clusterRoleName() string {
-	roleName := kt.spec.RoleName
-	if kt.spec.RoleName == kubeturbosv1.RoleTypeAdmin || kt.spec.RoleName == kubeturbosv1.RoleTypeReadOnly {
-		roleName = kt.spec.RoleName + "-" + kt.Name() + "-" + kt.Namespace()
+	roleName := kt.roleName()
+	if kt.generatesClusterRole() {
+		roleName = roleName + "-" + kt.Name() + "-" + kt.Namespace()
 	}
 	return roleName
 }
 
-func (kt *kubeturbo) clusterRole() *rbacv1.ClusterRole {
-	return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: kt.clusterRoleName()}}
+func (kt *kubeturbo) generatesClusterRole() bool {
+	return kt.roleName() == kubeturbosv1.RoleTypeAdmin || kt.roleName() == kubeturbosv1.RoleTypeReadOnly
 }
 
-func (kt *kubeturbo) createOrUpdateClusterRole() error {
-
-	// if roleName is cluster-admin or any custom names other than "turbo-cluster-admin" or "turbo-cluster-reader", don't override it
-	if kt.spec.RoleName == kubeturbosv1.RoleTypeClusterAdmin || (kt.spec.RoleName != kubeturbosv1.RoleTypeAdmin && kt.spec.RoleName != kubeturbosv1.RoleTypeReadOnly) {
-		return nil
-	}
-
-	cr := kt.clusterRole()
-
-	_, err := kt.CreateOrUpdate(cr, func() error {
-		return kt.mutateClusterRole(cr)
-	})
-	return err
+func (kt *kubeturbo) clusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: kt.clusterRoleName()}}
 }
 
 func (kt *kubeturbo) mutateClusterRole(cr *rbacv1.ClusterRole) error {
@@ -584,7 +814,7 @@ func (kt *kubeturbo) mutateClusterRole(cr *rbacv1.ClusterRole) error {
 	cr.Labels = kt.labels()
 
 	// turbo-cluster-reader
-	if kt.spec.RoleName == kubeturbosv1.RoleTypeReadOnly {
+	if kt.roleName() == kubeturbosv1.RoleTypeReadOnly {
 		cr.Rules = []rbacv1.PolicyRule{
 			{
 				APIGroups: []string{"", "apps", "app.k8s.io", "apps.openshift.io", "batch", "extensions", "turbonomic.com", "devops.turbonomic.io", "config.openshift.io"},
@@ -620,7 +850,7 @@ func (kt *kubeturbo) mutateClusterRole(cr *rbacv1.ClusterRole) error {
 				Verbs:     []string{"get", "list", "watch"},
 			},
 		}
-	} else if kt.spec.RoleName == kubeturbosv1.RoleTypeAdmin {
+	} else if kt.roleName() == kubeturbosv1.RoleTypeAdmin {
 		// turbo-cluster-admin
 		cr.Rules = []rbacv1.PolicyRule{
 			{
@@ -671,7 +901,8 @@ func (kt *kubeturbo) mutateClusterRole(cr *rbacv1.ClusterRole) error {
 		}
 	}
 
-	if kt.spec.OrmOwners.ApiGroup != nil && kt.spec.OrmOwners.Resources != nil {
+	// the ORM owners can be updated, which the action freeze rules out
+	if kt.spec.OrmOwners.ApiGroup != nil && kt.spec.OrmOwners.Resources != nil && !kt.actionsFrozen {
 		cr.Rules = append(cr.Rules, rbacv1.PolicyRule{
 			APIGroups: kt.spec.OrmOwners.ApiGroup,
 			Resources: kt.spec.OrmOwners.Resources,
@@ -686,16 +917,6 @@ func (kt *kubeturbo) clusterRoleBinding() *rbacv1.ClusterRoleBinding {
 	return &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: kt.spec.RoleBinding + "-" + kt.Name() + "-" + kt.Namespace()}}
 }
 
-func (kt *kubeturbo) createOrUpdateClusterRoleBinding() error {
-	crb := kt.clusterRoleBinding()
-	// TODO - this doesn't work on cluster-level resources
-	// kt.SetControllerReference(crb)
-	_, err := kt.CreateOrUpdate(crb, func() error {
-		return kt.mutateClusterRoleBinding(crb)
-	})
-	return err
-}
-
 func (kt *kubeturbo) mutateClusterRoleBinding(crb *rbacv1.ClusterRoleBinding) error {
 	// role ref cannot be updated in an existing role binding. Therefore,
 	// if role name is updated in the CR, delete the existing role binding before creating it
@@ -729,14 +950,24 @@ func (kt *kubeturbo) updateClusterResource() error {
 	if hashErr != nil {
 		return hashErr
 	}
-	// update hash once the hash got changed
+	revision, err := kt.getConfigRevision()
+	if err != nil {
+		return err
+	}
+	// update hash once the hash got changed, unless kubeturbo still runs with the old configuration
 	oldConfigHash := kt.Cr.Status.ConfigHash
+	if kt.restartHeldBack || (newConfigMapHash == oldConfigHash && revision == kt.Cr.Status.ConfigRevision) {
+		return nil
+	}
+	// the configuration kubeturbo runs with from now on becomes a revision
+	if err := kt.recordConfigRevision(revision); err != nil {
+		return err
+	}
 	if newConfigMapHash != oldConfigHash {
 		kt.Cr.Status.LastUpdatedTimestamp = time.Now().Format(time.RFC3339)
 		kt.Cr.Status.ConfigHash = newConfigMapHash
-		return kt.UpdateStatus()
 	}
-	return nil
+	return kt.UpdateStatus()
 }
 
 func (kt *kubeturbo) labels() map[string]string {
@@ -749,18 +980,24 @@ func (kt *kubeturbo) labels() map[string]string {
 
 func (kt *kubeturbo) cleanUpClusterResources() error {
 	//remove finalizer from service account
-	sa := kt.serviceAccount()
-	_, err := kt.CreateOrUpdate(sa, func() error {
-		return kt.mutateServiceAccount(sa, false)
-	})
+	owned, err := kt.ownsServiceAccount()
 	if err != nil {
 		return err
 	}
+	if owned {
+		sa := kt.serviceAccount()
+		_, err := kt.CreateOrUpdate(sa, func() error {
+			return kt.mutateServiceAccount(sa, false)
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	// delete cluster level objects created by the operator
 	return utils.ReturnOnError(
-		kt.cleanUpClusterRole,
-		kt.cleanUpClusterRolebinding,
+		kt.Traced("cleanUpClusterRole", kt.cleanUpClusterRole),
+		kt.Traced("cleanUpClusterRolebinding", kt.cleanUpClusterRolebinding),
 	)
 }
 
@@ -0,0 +1,62 @@
+package kubeturbo
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+// Runs the builders of the reconcile cycle on empty objects and returns the objects the
+// operator would create for the CR. The client is only read, e.g. to derive the target name
+func Render(ctx context.Context, c client.Client, scheme *runtime.Scheme, ktV1 *kubeturbosv1.Kubeturbo) ([]client.Object, error) {
+	logger := log.FromContext(ctx).WithName("Render")
+	kr := NewKubeturboRequest(c, c, ctx, scheme, nil, ktV1)
+	kt := kubeturbo{KubeturboRequest: kr, spec: kr.Cr.Spec, logger: logger}
+
+	if err := utils.ReturnOnError(
+		kt.Traced("resolveTargetName", kt.resolveTargetName),
+		kt.Traced("validateTrustedCA", kt.validateTrustedCA),
+	); err != nil {
+		return nil, err
+	}
+	// kubeturbo is bound to the reader role while the actions are frozen
+	freeze, err := kt.actionFreeze(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	kt.actionsFrozen = freeze.frozen
+
+	children, err := kt.childObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []client.Object
+	// there's no cluster to tell whether the Prometheus operator is installed, the
+	// ServiceMonitor is rendered whenever the metrics are enabled
+	for _, child := range children {
+		if !child.enabled {
+			continue
+		}
+		if child.owned {
+			kt.SetControllerReference(child.obj)
+		}
+		if err := child.mutate(child.obj); err != nil {
+			return nil, err
+		}
+		gvk, err := apiutil.GVKForObject(child.obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		child.obj.GetObjectKind().SetGroupVersionKind(gvk)
+		objs = append(objs, child.obj)
+	}
+	return objs, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
@@ -15,22 +16,32 @@ import (
 
 type KubeturboRequest struct {
 	request.BaseRequest[*kubeturbosv1.Kubeturbo]
+	// reads objects outside of the namespaces cached by the manager
+	APIReader client.Reader
 }
 
 func NewKubeturboRequest(
 	client client.Client,
+	apiReader client.Reader,
 	ctx context.Context,
 	scheme *runtime.Scheme,
+	recorder record.EventRecorder,
 	kt *kubeturbosv1.Kubeturbo,
 ) *KubeturboRequest {
 
+	if apiReader == nil {
+		apiReader = client
+	}
+
 	return &KubeturboRequest{
 		BaseRequest: request.BaseRequest[*kubeturbosv1.Kubeturbo]{
-			Cr:      kt,
-			Client:  client,
-			Context: ctx,
-			Scheme:  scheme,
+			Cr:       kt,
+			Client:   client,
+			Context:  ctx,
+			Scheme:   scheme,
+			Recorder: recorder,
 		},
+		APIReader: apiReader,
 	}
 }
 
@@ -0,0 +1,127 @@
+package kubeturbo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Restart settle window", func() {
+	var kt *kubeturbo
+
+	spec := newTestSpec()
+	spec.RestartSettleWindow = &metav1.Duration{Duration: time.Minute}
+
+	deploymentExists := func() bool {
+		return kt.Get(&appsv1.Deployment{ObjectMeta: kt.deployment().ObjectMeta}) == nil
+	}
+
+	BeforeEach(func() {
+		kt = newTestKubeturbo(spec)
+		Expect(kt.Get(kt.Cr)).To(Succeed())
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.updateClusterResource()).To(Succeed())
+		Expect(kt.Cr.Status.PendingRestart).To(BeNil())
+	})
+
+	changeConfig := func(targetName string) {
+		kt.spec.TargetConfig.TargetName = utils.AsPtr(targetName)
+	}
+
+	// the state of a reconcile cycle is dropped at its end
+	nextReconcile := func() {
+		kt = &kubeturbo{KubeturboRequest: kt.KubeturboRequest, spec: kt.spec, logger: kt.logger}
+	}
+
+	It("holds back the restart until the configuration settled", func() {
+		changeConfig("first")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.restartHeldBack).To(BeTrue())
+		Expect(time.Until(kt.requeue)).To(BeNumerically("~", time.Minute, time.Second))
+		Expect(kt.awaitSchedule()).To(BeAssignableToTypeOf(&constants.RequeueAfterError{}))
+		Expect(deploymentExists()).To(BeTrue())
+
+		pending := kt.Cr.Status.PendingRestart
+		Expect(pending).NotTo(BeNil())
+		firstHash, _ := kt.getKubeturboConfigHash()
+		Expect(pending.ConfigHash).To(Equal(firstHash))
+		Expect(pending.RestartAfter.Sub(pending.Since.Time)).To(Equal(time.Minute))
+
+		// another change within the window starts it over but keeps when the first change was seen
+		nextReconcile()
+		since := pending.Since
+		pending.RestartAfter = metav1.NewTime(time.Now().Add(-time.Second))
+		changeConfig("second")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		secondHash, _ := kt.getKubeturboConfigHash()
+		Expect(kt.Cr.Status.PendingRestart.ConfigHash).To(Equal(secondHash))
+		Expect(kt.Cr.Status.PendingRestart.Since).To(Equal(since))
+		Expect(deploymentExists()).To(BeTrue())
+
+		// a single restart once the window passed
+		nextReconcile()
+		kt.Cr.Status.PendingRestart.RestartAfter = metav1.NewTime(time.Now().Add(-time.Second))
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError(constants.ErrRequeueOnDeletion))
+		Expect(deploymentExists()).To(BeFalse())
+		Expect(kt.Cr.Status.PendingRestart).To(BeNil())
+		Expect(kt.Cr.Status.ConfigHash).To(Equal(secondHash))
+	})
+
+	It("applies the other changes while the restart waits", func() {
+		originalHash := kt.Cr.Status.ConfigHash
+		changeConfig("changed")
+		kt.spec.Image.Tag = utils.AsPtr("8.15.0")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		dep := kt.deployment()
+		Expect(kt.Get(dep)).To(Succeed())
+		Expect(kubeturboImage(dep)).To(HaveSuffix(":8.15.0"))
+
+		// kubeturbo still runs with the old turbo.config
+		Expect(kt.updateClusterResource()).To(Succeed())
+		Expect(kt.Cr.Status.ConfigHash).To(Equal(originalHash))
+	})
+
+	It("drops the pending restart when the changes are reverted", func() {
+		originalHash := kt.Cr.Status.ConfigHash
+		changeConfig("changed")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.Cr.Status.PendingRestart).NotTo(BeNil())
+
+		nextReconcile()
+		kt.spec.TargetConfig.TargetName = spec.TargetConfig.TargetName
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(Succeed())
+		Expect(kt.Cr.Status.PendingRestart).To(BeNil())
+		Expect(kt.Cr.Status.ConfigHash).To(Equal(originalHash))
+		Expect(deploymentExists()).To(BeTrue())
+	})
+
+	It("restarts right away without a settle window", func() {
+		kt.spec.RestartSettleWindow = nil
+		changeConfig("changed")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError(constants.ErrRequeueOnDeletion))
+		Expect(kt.Cr.Status.PendingRestart).To(BeNil())
+		Expect(deploymentExists()).To(BeFalse())
+	})
+
+	It("keeps kubeturbo running when the new hash can't be recorded", func() {
+		kt.spec.RestartSettleWindow = nil
+		kt.Client = interceptor.NewClient(kt.Client.(client.WithWatch), interceptor.Funcs{
+			SubResourceUpdate: func(context.Context, client.Client, string, client.Object, ...client.SubResourceUpdateOption) error {
+				return fmt.Errorf("status unavailable")
+			},
+		})
+		changeConfig("changed")
+		Expect(kt.reconcileStep("createOrUpdateDeployment")).To(MatchError("status unavailable"))
+		Expect(deploymentExists()).To(BeTrue())
+	})
+})
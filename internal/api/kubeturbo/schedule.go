@@ -0,0 +1,56 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+)
+
+// how long a window opened by a cron schedule lasts if the CR doesn't tell
+const defaultWindowDuration = time.Hour
+
+// Whether a window opened by the cron schedule, e.g. "0 2 * * 6", is open at the given time.
+// Returns the opening of the current window if open, otherwise of the next one
+func cronWindow(schedule, timeZone string, duration time.Duration, now time.Time) (bool, time.Time, error) {
+	spec := schedule
+	if timeZone != "" {
+		spec = fmt.Sprintf("CRON_TZ=%s %s", timeZone, spec)
+	}
+	parsed, err := cron.ParseStandard(spec)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if duration <= 0 {
+		duration = defaultWindowDuration
+	}
+
+	// the window is open if it opened within the last duration, otherwise that's its next opening
+	opening := parsed.Next(now.Add(-duration))
+	if opening.IsZero() {
+		return false, time.Time{}, fmt.Errorf("schedule %q never fires", schedule)
+	}
+	return !opening.After(now), opening, nil
+}
+
+// Reconciles the CR again at the given time, e.g. when a window opens, keeping the earliest time
+func (kt *kubeturbo) requeueAt(t time.Time, reason string) {
+	if kt.requeue.IsZero() || t.Before(kt.requeue) {
+		kt.requeue = t
+		kt.requeueReason = reason
+	}
+}
+
+// Retries the reconcile at the time a scheduled change is due. The other changes are applied by then
+func (kt *kubeturbo) awaitSchedule() error {
+	if kt.requeue.IsZero() {
+		return nil
+	}
+	kt.logger.Info("Reconcile scheduled", "at", kt.requeue, "reason", kt.requeueReason)
+	return &constants.RequeueAfterError{
+		Delay:  max(time.Until(kt.requeue), time.Second),
+		Reason: kt.requeueReason,
+	}
+}
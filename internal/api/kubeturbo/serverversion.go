@@ -0,0 +1,65 @@
+package kubeturbo
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/turboserver"
+)
+
+const (
+	reasonVersionSkewSupported = "SkewSupported"
+	reasonVersionSkewExceeded  = "SkewExceeded"
+	reasonVersionUnknown       = "VersionUnknown"
+)
+
+// Switches the protocol version written to turbo.config to the version reported by the
+// Turbo Server, if requested, and warns when kubeturbo and the Turbo Server are too far apart.
+// Relies on the version recorded by the connectivity check for the configured Turbo Server
+func (kt *kubeturbo) resolveServerVersion() error {
+	serverVersion := kt.Cr.Status.ServerVersion
+	if serverVersion == "" || kt.Cr.Status.ServerURL != kt.spec.ServerMeta.TurboServer {
+		// the compatibility reported for the previous Turbo server no longer holds
+		if meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionVersionCompatible) == nil {
+			return nil
+		}
+		message := fmt.Sprintf("Turbo server %s hasn't reported its version yet", kt.spec.ServerMeta.TurboServer)
+		return kt.setCondition(kubeturbosv1.ConditionVersionCompatible, metav1.ConditionUnknown, reasonVersionUnknown, message)
+	}
+
+	resolve := kt.spec.ServerMeta.ResolveVersion
+	if resolve != nil && *resolve {
+		kt.spec.ServerMeta.Version = &serverVersion
+	}
+
+	return kt.checkVersionSkew(serverVersion)
+}
+
+func (kt *kubeturbo) checkVersionSkew(serverVersion string) error {
+	tag := ""
+	if kt.spec.Image.Tag != nil {
+		tag = *kt.spec.Image.Tag
+	}
+	kubeturboVersion, err := turboserver.ParseVersion(tag)
+	if err != nil {
+		message := fmt.Sprintf("Unable to compare the kubeturbo image tag with the Turbo server version %s: %s", serverVersion, err)
+		return kt.setCondition(kubeturbosv1.ConditionVersionCompatible, metav1.ConditionUnknown, reasonVersionUnknown, message)
+	}
+	parsedServerVersion, err := turboserver.ParseVersion(serverVersion)
+	if err != nil {
+		message := fmt.Sprintf("Unable to compare the kubeturbo version %s with the Turbo server version: %s", tag, err)
+		return kt.setCondition(kubeturbosv1.ConditionVersionCompatible, metav1.ConditionUnknown, reasonVersionUnknown, message)
+	}
+
+	if !turboserver.WithinSupportedSkew(kubeturboVersion, parsedServerVersion) {
+		message := fmt.Sprintf("kubeturbo %s is not supported with Turbo server %s, the versions may be at most %d minor version(s) apart",
+			tag, serverVersion, turboserver.MaxMinorVersionSkew)
+		kt.logger.Info("Warning: " + message)
+		return kt.setCondition(kubeturbosv1.ConditionVersionCompatible, metav1.ConditionFalse, reasonVersionSkewExceeded, message)
+	}
+	message := fmt.Sprintf("kubeturbo %s is supported with Turbo server %s", tag, serverVersion)
+	return kt.setCondition(kubeturbosv1.ConditionVersionCompatible, metav1.ConditionTrue, reasonVersionSkewSupported, message)
+}
@@ -0,0 +1,111 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Server version", func() {
+	// a kubeturbo CR whose connectivity check recorded the given Turbo server version
+	newReportedKubeturbo := func(serverVersion string) *kubeturbo {
+		kt := newTestKubeturbo(newTestSpec())
+		kt.Cr.Status.ServerVersion = serverVersion
+		kt.Cr.Status.ServerURL = kt.spec.ServerMeta.TurboServer
+		return kt
+	}
+
+	versionCompatible := func(kt *kubeturbo) *metav1.Condition {
+		return meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionVersionCompatible)
+	}
+
+	It("Writes the version reported by the Turbo server to turbo.config if requested", func() {
+		kt := newReportedKubeturbo("8.15.1")
+		kt.spec.ServerMeta.ResolveVersion = utils.AsPtr(true)
+
+		Expect(kt.resolveServerVersion()).To(Succeed())
+
+		config, err := kt.buildKubeturboConfig()
+		Expect(err).NotTo(HaveOccurred())
+		var turboConfig struct {
+			CommunicationConfig struct {
+				ServerMeta struct {
+					Version string `json:"version"`
+				} `json:"serverMeta"`
+			} `json:"communicationConfig"`
+		}
+		Expect(json.Unmarshal(config, &turboConfig)).To(Succeed())
+		Expect(turboConfig.CommunicationConfig.ServerMeta.Version).To(Equal("8.15.1"))
+	})
+
+	It("Keeps the version of the spec unless requested", func() {
+		kt := newReportedKubeturbo("8.15.1")
+
+		Expect(kt.resolveServerVersion()).To(Succeed())
+
+		Expect(*kt.spec.ServerMeta.Version).To(Equal("8.14.3"))
+	})
+
+	It("Reports kubeturbo as compatible within the supported skew", func() {
+		kt := newReportedKubeturbo("8.15.1")
+
+		Expect(kt.resolveServerVersion()).To(Succeed())
+
+		condition := versionCompatible(kt)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(reasonVersionSkewSupported))
+	})
+
+	It("Reports kubeturbo as incompatible beyond the supported skew", func() {
+		kt := newReportedKubeturbo("8.9.0")
+
+		Expect(kt.resolveServerVersion()).To(Succeed())
+
+		condition := versionCompatible(kt)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(reasonVersionSkewExceeded))
+	})
+
+	It("Reports the compatibility as unknown for an image tag that isn't a version", func() {
+		kt := newReportedKubeturbo("8.15.1")
+		kt.spec.Image.Tag = utils.AsPtr("latest")
+
+		Expect(kt.resolveServerVersion()).To(Succeed())
+
+		condition := versionCompatible(kt)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(condition.Reason).To(Equal(reasonVersionUnknown))
+	})
+
+	When("serverMeta.turboServer changes", func() {
+		It("Forgets the version of the previous Turbo server", func() {
+			kt := newReportedKubeturbo("8.15.1")
+			kt.spec.ServerMeta.ResolveVersion = utils.AsPtr(true)
+			Expect(kt.resolveServerVersion()).To(Succeed())
+			Expect(versionCompatible(kt).Status).To(Equal(metav1.ConditionTrue))
+
+			kt.spec = newTestSpec()
+			kt.spec.ServerMeta.ResolveVersion = utils.AsPtr(true)
+			kt.spec.ServerMeta.TurboServer = "https://" + turboServerPlaceholder
+
+			Expect(kt.checkServerConnectivity()).To(Succeed())
+			Expect(kt.resolveServerVersion()).To(Succeed())
+
+			Expect(kt.Cr.Status.ServerVersion).To(BeEmpty())
+			Expect(kt.Cr.Status.ServerURL).To(BeEmpty())
+			Expect(*kt.spec.ServerMeta.Version).To(Equal("8.14.3"))
+			condition := versionCompatible(kt)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		})
+	})
+})
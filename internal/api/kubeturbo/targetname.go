@@ -0,0 +1,71 @@
+package kubeturbo
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+)
+
+const (
+	defaultTargetNamePrefix = "cluster"
+	// the kube-system namespace exists for the lifetime of a cluster, its UID identifies the cluster
+	clusterIdentityNamespace = "kube-system"
+	// length of the UID fragment in a derived target name
+	clusterIDLength = 8
+)
+
+// Returns the target name kubeturbo registers with for the given spec, or an empty string
+// when kubeturbo chooses the name by itself
+func targetName(spec kubeturbosv1.KubeturboSpec, clusterUID types.UID) string {
+	if spec.TargetConfig.TargetName != nil {
+		return *spec.TargetConfig.TargetName
+	}
+	derive := spec.TargetConfig.DeriveTargetName
+	if derive == nil || !*derive || clusterUID == "" {
+		return ""
+	}
+	prefix := defaultTargetNamePrefix
+	if spec.TargetConfig.TargetNamePrefix != nil && *spec.TargetConfig.TargetNamePrefix != "" {
+		prefix = *spec.TargetConfig.TargetNamePrefix
+	}
+	clusterID := string(clusterUID)
+	if len(clusterID) > clusterIDLength {
+		clusterID = clusterID[:clusterIDLength]
+	}
+	return fmt.Sprintf("%s-%s", prefix, clusterID)
+}
+
+func derivesTargetName(spec kubeturbosv1.KubeturboSpec) bool {
+	derive := spec.TargetConfig.DeriveTargetName
+	return spec.TargetConfig.TargetName == nil && derive != nil && *derive
+}
+
+// Returns the UID of the kube-system namespace, which stays the same for the lifetime of the cluster
+func (kt *kubeturbo) clusterUID() (types.UID, error) {
+	if kt.clusterID != "" {
+		return kt.clusterID, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := kt.APIReader.Get(kt.Context, types.NamespacedName{Name: clusterIdentityNamespace}, ns); err != nil {
+		return "", fmt.Errorf("unable to identify the cluster by the %s namespace: %w", clusterIdentityNamespace, err)
+	}
+	kt.clusterID = ns.UID
+	return kt.clusterID, nil
+}
+
+// Writes the derived target name to the spec, so that it ends up in turbo.config
+func (kt *kubeturbo) resolveTargetName() error {
+	if !derivesTargetName(kt.spec) {
+		return nil
+	}
+	clusterUID, err := kt.clusterUID()
+	if err != nil {
+		return err
+	}
+	name := targetName(kt.spec, clusterUID)
+	kt.spec.TargetConfig.TargetName = &name
+	return nil
+}
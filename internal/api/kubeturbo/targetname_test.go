@@ -0,0 +1,78 @@
+package kubeturbo
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Target name", func() {
+	kubeSystem := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "6f1c2d3e-aaaa-bbbb-cccc-0123456789ab"},
+	}
+	deriveSpec := kubeturbosv1.KubeturboSpec{
+		TargetConfig: kubeturbosv1.KubeturboTargetConfig{DeriveTargetName: utils.AsPtr(true)},
+	}
+
+	It("Derives the target name from the kube-system namespace", func() {
+		kt := newTestKubeturbo(deriveSpec, kubeSystem)
+
+		Expect(kt.resolveTargetName()).To(Succeed())
+		Expect(*kt.spec.TargetConfig.TargetName).To(Equal("cluster-6f1c2d3e"))
+	})
+
+	It("Prefers an explicit target name", func() {
+		spec := *deriveSpec.DeepCopy()
+		spec.TargetConfig.TargetName = utils.AsPtr("production")
+		kt := newTestKubeturbo(spec, kubeSystem)
+
+		Expect(kt.resolveTargetName()).To(Succeed())
+		Expect(*kt.spec.TargetConfig.TargetName).To(Equal("production"))
+	})
+
+	It("Blocks a CR that registers the same target name as an older CR", func() {
+		older := &kubeturbosv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kubeturbo-old", Namespace: "turbo-old", UID: "5678",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: kubeturbosv1.KubeturboSpec{
+				TargetConfig: kubeturbosv1.KubeturboTargetConfig{TargetName: utils.AsPtr("cluster-6f1c2d3e")},
+			},
+		}
+		kt := newTestKubeturbo(deriveSpec, kubeSystem, older)
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.resolveTargetName()).To(Succeed())
+		Expect(kt.checkConflicts()).To(MatchError(constants.ErrReconcileBlocked))
+
+		condition := meta.FindStatusCondition(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(reasonTargetNameConflict))
+		Expect(condition.Message).To(ContainSubstring("turbo-old/kubeturbo-old"))
+	})
+
+	It("Doesn't block the older CR", func() {
+		newer := &kubeturbosv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kubeturbo-new", Namespace: "turbo-new", UID: "5678",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+			},
+			Spec: deriveSpec,
+		}
+		kt := newTestKubeturbo(deriveSpec, kubeSystem, newer)
+		kt.Cr.CreationTimestamp = metav1.Now()
+
+		Expect(kt.resolveTargetName()).To(Succeed())
+		Expect(kt.checkConflicts()).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(kt.Cr.Status.Conditions, kubeturbosv1.ConditionConflicted)).To(BeTrue())
+	})
+})
@@ -0,0 +1,83 @@
+package kubeturbo
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/tracing"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		kt       *kubeturbo
+		exporter *tracetest.InMemoryExporter
+	)
+
+	spec := newTestSpec()
+
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(provider)
+		DeferCleanup(func() { otel.SetTracerProvider(previous) })
+
+		kt = newTestKubeturbo(spec)
+	})
+
+	spanNamed := func(name string) tracetest.SpanStub {
+		for _, span := range exporter.GetSpans() {
+			if span.Name == name {
+				return span
+			}
+		}
+		Fail("no span named " + name)
+		return tracetest.SpanStub{}
+	}
+
+	It("traces the API calls of a step as its children", func() {
+		Expect(kt.Traced("createOrUpdateServiceAccount", func() error { return kt.reconcileStep("createOrUpdateServiceAccount") })()).To(Succeed())
+
+		step := spanNamed("createOrUpdateServiceAccount")
+		Expect(step.Attributes).To(ContainElements(
+			tracing.NamespaceKey.String(kt.Namespace()),
+			tracing.NameKey.String(kt.Name()),
+		))
+		Expect(step.Status.Code).To(Equal(codes.Unset))
+
+		call := spanNamed("CreateOrUpdate ServiceAccount")
+		Expect(call.Parent.SpanID()).To(Equal(step.SpanContext.SpanID()))
+		Expect(call.Attributes).To(ContainElements(
+			tracing.NamespaceKey.String(kt.Namespace()),
+			tracing.NameKey.String(kt.Name()),
+			attribute.String("k8s.object.kind", "ServiceAccount"),
+			attribute.String("k8s.object.name", kt.serviceAccountName()),
+		))
+
+		// the API calls after the step aren't its children
+		Expect(kt.UpdateStatus()).To(Succeed())
+		Expect(spanNamed("UpdateStatus Kubeturbo").Parent.IsValid()).To(BeFalse())
+	})
+
+	It("marks the span of a failed step", func() {
+		err := errors.New("boom")
+		Expect(kt.Traced("failing", func() error { return err })()).To(MatchError(err))
+
+		step := spanNamed("failing")
+		Expect(step.Status.Code).To(Equal(codes.Error))
+		Expect(step.Status.Description).To(Equal("boom"))
+	})
+
+	It("traces every step of the reconcile", func() {
+		_ = kt.reconcileKubeTurbo()
+
+		Expect(spanNamed("adoptLegacyKubeturbo").Attributes).To(ContainElement(tracing.NameKey.String(kt.Name())))
+	})
+})
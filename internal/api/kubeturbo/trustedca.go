@@ -0,0 +1,95 @@
+package kubeturbo
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+const (
+	trustedCAVolumeName = "trusted-ca-volume"
+	trustedCAMountPath  = "/etc/kubeturbo-trusted-ca"
+	trustedCAFileName   = "ca-bundle.crt"
+	// the directories Go loads certificates from on Linux unless SSL_CERT_DIR is set
+	defaultCertDirs = "/etc/ssl/certs:/etc/pki/tls/certs"
+
+	// The OpenShift cluster network operator fills ConfigMaps carrying this label
+	// with the cluster-wide trusted CA bundle under the ca-bundle.crt key
+	openShiftInjectCALabelKey = "config.openshift.io/inject-trusted-cabundle"
+)
+
+func (kt *kubeturbo) hasTrustedCA() bool {
+	ca := kt.spec.ServerMeta.TrustedCA
+	return ca.ConfigMapName != nil || ca.SecretName != nil || kt.injectOpenShiftTrustedCA()
+}
+
+func (kt *kubeturbo) injectOpenShiftTrustedCA() bool {
+	return kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA != nil && *kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA
+}
+
+func (kt *kubeturbo) trustedCAKey() string {
+	if kt.spec.ServerMeta.TrustedCA.Key == "" {
+		return trustedCAFileName
+	}
+	return kt.spec.ServerMeta.TrustedCA.Key
+}
+
+func (kt *kubeturbo) trustedCAConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprint("trusted-ca", "-", kt.Name()), Namespace: kt.Namespace()}}
+}
+
+func (kt *kubeturbo) validateTrustedCA() error {
+	ca := kt.spec.ServerMeta.TrustedCA
+	sources := 0
+	for _, set := range []bool{ca.ConfigMapName != nil, ca.SecretName != nil, kt.injectOpenShiftTrustedCA()} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of serverMeta.trustedCA.configMapName, serverMeta.trustedCA.secretName and serverMeta.trustedCA.injectOpenShiftTrustedCA can be specified")
+	}
+	return nil
+}
+
+// Creates the ConfigMap that OpenShift injects the trusted CA bundle into.
+// The data is owned by the cluster network operator, so only the metadata is managed here
+func (kt *kubeturbo) mutateTrustedCAConfigMap(cm *corev1.ConfigMap) error {
+	cm.ObjectMeta.Labels = utils.NewMapBuilder[string, string]().
+		PutAll(kt.labels()).
+		Put(openShiftInjectCALabelKey, "true").
+		Build()
+	return nil
+}
+
+func (kt *kubeturbo) trustedCAVolume() *corev1.Volume {
+	ca := kt.spec.ServerMeta.TrustedCA
+	// always project the bundle to the same file name regardless of the source key
+	items := []corev1.KeyToPath{{Key: kt.trustedCAKey(), Path: trustedCAFileName}}
+	volume := &corev1.Volume{Name: trustedCAVolumeName}
+	switch {
+	case ca.ConfigMapName != nil:
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: *ca.ConfigMapName},
+			Items:                items,
+		}
+	case ca.SecretName != nil:
+		volume.Secret = &corev1.SecretVolumeSource{
+			SecretName: *ca.SecretName,
+			Items:      items,
+		}
+	case kt.injectOpenShiftTrustedCA():
+		// the injected bundle always uses the ca-bundle.crt key, which may not be
+		// populated yet when the pod starts
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: kt.trustedCAConfigMap().Name},
+			Optional:             utils.AsPtr(true),
+		}
+	default:
+		return nil
+	}
+	return volume
+}
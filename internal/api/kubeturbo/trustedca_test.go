@@ -0,0 +1,98 @@
+package kubeturbo
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Trusted CA", func() {
+	It("Mounts the bundle from a Secret under a fixed file name", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			ServerMeta: kubeturbosv1.KubeturboServerMeta{
+				TrustedCA: kubeturbosv1.KubeturboTrustedCA{SecretName: utils.AsPtr("turbo-server-ca"), Key: "ca.pem"},
+			},
+		})
+
+		volume := kt.trustedCAVolume()
+		Expect(volume.Secret.SecretName).To(Equal("turbo-server-ca"))
+		Expect(volume.Secret.Items).To(Equal([]corev1.KeyToPath{{Key: "ca.pem", Path: "ca-bundle.crt"}}))
+		Expect(kt.volumeMounts()).To(ContainElement(HaveField("MountPath", "/etc/kubeturbo-trusted-ca")))
+	})
+
+	It("Adds the bundle directory to the default certificate directories", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			ServerMeta: kubeturbosv1.KubeturboServerMeta{
+				TrustedCA: kubeturbosv1.KubeturboTrustedCA{ConfigMapName: utils.AsPtr("turbo-server-ca")},
+			},
+		})
+
+		env, err := kt.containerEnv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(envValue(env, "SSL_CERT_DIR")).To(Equal("/etc/kubeturbo-trusted-ca:/etc/ssl/certs:/etc/pki/tls/certs"))
+	})
+
+	It("Leaves the certificates alone without a trusted CA", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{})
+
+		env, err := kt.containerEnv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(envValue(env, "SSL_CERT_DIR")).To(BeEmpty())
+		Expect(kt.trustedCAVolume()).To(BeNil())
+	})
+
+	It("Rejects more than one source", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			ServerMeta: kubeturbosv1.KubeturboServerMeta{
+				TrustedCA: kubeturbosv1.KubeturboTrustedCA{
+					ConfigMapName:            utils.AsPtr("turbo-server-ca"),
+					InjectOpenShiftTrustedCA: utils.AsPtr(true),
+				},
+			},
+		})
+
+		Expect(kt.validateTrustedCA()).NotTo(Succeed())
+	})
+
+	It("Creates the ConfigMap OpenShift injects the cluster bundle into", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			ServerMeta: kubeturbosv1.KubeturboServerMeta{
+				TrustedCA: kubeturbosv1.KubeturboTrustedCA{InjectOpenShiftTrustedCA: utils.AsPtr(true)},
+			},
+		})
+
+		Expect(kt.reconcileStep("createOrUpdateTrustedCAConfigMap")).To(Succeed())
+		cm := kt.trustedCAConfigMap()
+		Expect(kt.Get(cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue("config.openshift.io/inject-trusted-cabundle", "true"))
+		Expect(cm.OwnerReferences).To(ConsistOf(HaveField("Name", "kubeturbo-release")))
+		Expect(kt.trustedCAVolume().ConfigMap.Name).To(Equal(cm.Name))
+		Expect(*kt.trustedCAVolume().ConfigMap.Optional).To(BeTrue())
+	})
+
+	It("Deletes its ConfigMap once the injection is turned off", func() {
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{
+			ServerMeta: kubeturbosv1.KubeturboServerMeta{
+				TrustedCA: kubeturbosv1.KubeturboTrustedCA{InjectOpenShiftTrustedCA: utils.AsPtr(true)},
+			},
+		})
+		Expect(kt.reconcileStep("createOrUpdateTrustedCAConfigMap")).To(Succeed())
+
+		kt.spec.ServerMeta.TrustedCA.InjectOpenShiftTrustedCA = utils.AsPtr(false)
+		Expect(kt.reconcileStep("createOrUpdateTrustedCAConfigMap")).To(Succeed())
+		Expect(errors.IsNotFound(kt.Get(kt.trustedCAConfigMap()))).To(BeTrue())
+	})
+
+	It("Keeps a ConfigMap of the same name it doesn't own", func() {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "trusted-ca-kubeturbo-release", Namespace: "turbo"}}
+		kt := newTestKubeturbo(kubeturbosv1.KubeturboSpec{}, cm)
+
+		Expect(kt.reconcileStep("createOrUpdateTrustedCAConfigMap")).To(Succeed())
+		Expect(kt.Get(cm)).To(Succeed())
+	})
+})
@@ -0,0 +1,128 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The service account the operator runs as in the OLM bundle
+const OperatorServiceAccount = "kubeturbo-operator"
+
+// Replaces the clusterPermissions of the ClusterServiceVersion with a single entry granting the rules of the
+// ClusterRoles to the service account. The rules of several roles are merged in the order given.
+// The rest of the CSV, its key order and comments included, is kept as it is
+func SetClusterPermissions(csv []byte, serviceAccountName string, clusterRoles ...[]byte) ([]byte, error) {
+	rules := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, clusterRole := range clusterRoles {
+		roleRules, err := clusterRoleRules(clusterRole)
+		if err != nil {
+			return nil, err
+		}
+		rules.Content = append(rules.Content, roleRules...)
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(csv, doc); err != nil {
+		return nil, fmt.Errorf("invalid ClusterServiceVersion: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New("the ClusterServiceVersion is empty")
+	}
+	if kind := lookup(doc.Content[0], "kind"); kind == nil || kind.Value != "ClusterServiceVersion" {
+		return nil, errors.New("not a ClusterServiceVersion")
+	}
+	installSpec := lookup(doc.Content[0], "spec", "install", "spec")
+	if installSpec == nil || installSpec.Kind != yaml.MappingNode {
+		return nil, errors.New("the ClusterServiceVersion has no spec.install.spec")
+	}
+
+	permissions := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			scalar("rules"), rules,
+			scalar("serviceAccountName"), scalar(serviceAccountName),
+		},
+	}}}
+	setKey(installSpec, "clusterPermissions", permissions)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Returns the rules of the ClusterRoles in the manifest, which may hold several documents
+func clusterRoleRules(manifest []byte) ([]*yaml.Node, error) {
+	var rules []*yaml.Node
+	found := false
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid ClusterRole: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if kind := lookup(doc.Content[0], "kind"); kind == nil || kind.Value != "ClusterRole" {
+			continue
+		}
+		found = true
+		if roleRules := lookup(doc.Content[0], "rules"); roleRules != nil && roleRules.Kind == yaml.SequenceNode {
+			rules = append(rules, roleRules.Content...)
+		}
+	}
+	if !found {
+		return nil, errors.New("the manifest holds no ClusterRole")
+	}
+	return rules, nil
+}
+
+// Walks the keys of nested mappings, returns nil if one of them is missing
+func lookup(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				value = node.Content[i+1]
+				break
+			}
+		}
+		if value == nil {
+			return nil
+		}
+		node = value
+	}
+	return node
+}
+
+// Replaces the value of the key in the mapping, or appends the key if it is missing
+func setKey(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, scalar(key), value)
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
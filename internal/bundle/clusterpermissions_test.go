@@ -0,0 +1,97 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/bundle"
+)
+
+var _ = Describe("SetClusterPermissions", func() {
+	fixture := func(name string) []byte {
+		content, err := os.ReadFile(filepath.Join("testdata", name))
+		Expect(err).NotTo(HaveOccurred())
+		return content
+	}
+
+	// the parts of the CSV the tests look at
+	type clusterServiceVersion struct {
+		Spec struct {
+			Install struct {
+				Spec struct {
+					ClusterPermissions []struct {
+						Rules              []rbacv1.PolicyRule `json:"rules"`
+						ServiceAccountName string              `json:"serviceAccountName"`
+					} `json:"clusterPermissions"`
+					Deployments []interface{} `json:"deployments"`
+				} `json:"spec"`
+			} `json:"install"`
+		} `json:"spec"`
+	}
+	parse := func(csv []byte) clusterServiceVersion {
+		parsed := clusterServiceVersion{}
+		Expect(yaml.Unmarshal(csv, &parsed)).To(Succeed())
+		return parsed
+	}
+
+	It("Replaces the placeholder permissions and keeps the rest of the CSV", func() {
+		csv, err := bundle.SetClusterPermissions(fixture("clusterserviceversion.yaml"), bundle.OperatorServiceAccount, fixture("cluster_role.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(csv)).To(Equal(string(fixture("clusterserviceversion_expected.yaml"))))
+
+		// running it again changes nothing
+		again, err := bundle.SetClusterPermissions(csv, bundle.OperatorServiceAccount, fixture("cluster_role.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(again)).To(Equal(string(csv)))
+	})
+
+	It("Adds the permissions to a CSV without them", func() {
+		csv, err := bundle.SetClusterPermissions(fixture("clusterserviceversion_without_permissions.yaml"), "operator", fixture("cluster_role.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		install := parse(csv).Spec.Install.Spec
+		Expect(install.Deployments).To(HaveLen(1))
+		Expect(install.ClusterPermissions).To(HaveLen(1))
+		Expect(install.ClusterPermissions[0].ServiceAccountName).To(Equal("operator"))
+		Expect(install.ClusterPermissions[0].Rules).To(HaveLen(2))
+	})
+
+	It("Merges the rules of the ClusterRoles and skips other kinds", func() {
+		csv, err := bundle.SetClusterPermissions(fixture("clusterserviceversion.yaml"), bundle.OperatorServiceAccount,
+			fixture("cluster_role.yaml"), fixture("generated_roles.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		rules := parse(csv).Spec.Install.Spec.ClusterPermissions[0].Rules
+		Expect(rules).To(HaveLen(3))
+		Expect(rules[2]).To(Equal(rbacv1.PolicyRule{
+			APIGroups: []string{"charts.helm.k8s.io"},
+			Resources: []string{"kubeturbos"},
+			Verbs:     []string{"get", "list"},
+		}))
+	})
+
+	It("Writes the rules of the operator ClusterRole into the certified bundle CSV", func() {
+		csv, err := bundle.SetClusterPermissions(
+			fixture("../../../certified-bundle-config/manifests/bases/kubeturbo-certified.clusterserviceversion.yaml"),
+			bundle.OperatorServiceAccount,
+			fixture("../../../config/rbac/kubeturbo-operator-cluster-role.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		clusterRole := rbacv1.ClusterRole{}
+		Expect(yaml.Unmarshal(fixture("../../../config/rbac/kubeturbo-operator-cluster-role.yaml"), &clusterRole)).To(Succeed())
+		Expect(parse(csv).Spec.Install.Spec.ClusterPermissions[0].Rules).To(Equal(clusterRole.Rules))
+	})
+
+	It("Rejects manifests of the wrong kind", func() {
+		_, err := bundle.SetClusterPermissions(fixture("cluster_role.yaml"), bundle.OperatorServiceAccount, fixture("cluster_role.yaml"))
+		Expect(err).To(MatchError("not a ClusterServiceVersion"))
+
+		_, err = bundle.SetClusterPermissions(fixture("clusterserviceversion.yaml"), bundle.OperatorServiceAccount, fixture("clusterserviceversion.yaml"))
+		Expect(err).To(MatchError("the manifest holds no ClusterRole"))
+	})
+})
@@ -1,6 +1,10 @@
 package constants
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 const (
 	OperatorName = "kubeturbo-operator"
@@ -18,11 +22,47 @@ const (
 	KubeturboComponentType = "kubeturbo"
 	KubeturboAnnotation    = "charts.helm.k8s.io/kubeturbo"
 	ControlGenAnnotation   = "controller-gen.kubebuilder.io/version"
+	// set to "true" on a CR to stop the operator from changing the kubeturbo resources
+	PausedAnnotation = "kubeturbo.io/paused"
+	// set to "true" on a CR to publish the changes the operator would make instead of applying them,
+	// takes effect on a paused CR as well
+	PlanAnnotation = "kubeturbo.io/plan"
+	// set on a CR to take over a kubeturbo deployed with the helm chart or YAML manifests, either
+	// to the name of its Deployment or to "true" to look for it in the namespace of the CR
+	AdoptAnnotation = "kubeturbo.io/adopt"
+	// set on a CR to apply the turbo.config and turbo-autoreload.config of a past revision, listed by the
+	// config revision ConfigMaps, instead of the ones rendered from the spec
+	RollbackAnnotation = "kubeturbo.io/rollback-to"
+	// the revision of the configuration held by a config revision ConfigMap
+	ConfigRevisionLabelKey = "kubeturbo.io/config-revision"
+	// the hash of the pod template the operator rendered for the kubeturbo Deployment
+	PodTemplateHashAnnotation = "kubeturbo.io/pod-template-hash"
 
 	KubeturboFinalizer = "helm.k8s.io/finalizer"
 
+	// the port kubeturbo serves its health and metrics endpoints on, passed with the --port flag
+	// of kubeturbo rather than relying on its default
+	KubeturboPort = 10265
+	// the health endpoint kubeturbo installs on its port
+	KubeturboHealthPath = "/healthz"
+
 	RequeueDelaySeconds = 1
 	TimeoutInSeconds    = 5
+	// how often a blocked CR checks if the reason it got blocked is gone
+	BlockedRequeueDelaySeconds = 60
 )
 
 var ErrRequeueOnDeletion = errors.New("resource deletion detected")
+
+// returned when the CR must not be reconciled, the reason is reported in the CR status
+var ErrReconcileBlocked = errors.New("reconciliation blocked")
+
+// returned when the reconcile must be retried after a delay, e.g. to let changes settle
+type RequeueAfterError struct {
+	Delay  time.Duration
+	Reason string
+}
+
+func (e *RequeueAfterError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", e.Reason, e.Delay)
+}
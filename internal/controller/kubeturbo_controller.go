@@ -20,19 +20,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/api/kubeturbo"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/health"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/metrics"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/reconcile"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/tracing"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -42,8 +49,13 @@ import (
 // KubeturboReconciler reconciles a Kubeturbo object
 type KubeturboReconciler struct {
 	client.Client
+	// APIReader reads directly from the API server, e.g. objects outside of the watched namespace
+	APIReader     client.Reader
 	Scheme        *runtime.Scheme
+	Recorder      record.EventRecorder
 	PostCheckDone *chan interface{}
+	// Watchdog tracks the reconciles in progress for the liveness check
+	Watchdog *health.ReconcileWatchdog
 }
 
 //+kubebuilder:rbac:groups=charts.helm.k8s.io,resources=kubeturbos,verbs=get;list;watch;create;update;patch;delete
@@ -65,6 +77,18 @@ func (r *KubeturboReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		<-*r.PostCheckDone
 	}
 
+	// Waiting for the pre-checks above is expected, only the reconcile itself may get stuck
+	if r.Watchdog != nil {
+		defer r.Watchdog.Track()()
+	}
+
+	ctx, span := tracing.Start(ctx, "Reconcile", req.Namespace, req.Name)
+	result, err := r.reconcileKubeturbo(ctx, req)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (r *KubeturboReconciler) reconcileKubeturbo(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	logger.Info("Reconciling...")
@@ -87,6 +111,8 @@ func (r *KubeturboReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	err = kt.SetSpecDefault()
 	if err != nil {
 		logger.Error(err, "")
+		metrics.ValidationFailures.WithLabelValues(kt.Namespace, kt.Name, "InvalidSpec").Inc()
+		r.event(&kt, corev1.EventTypeWarning, "InvalidSpec", fmt.Sprintf("Failed to apply the defaults of the spec: %s", err))
 		return reconcile.DoNotRequeue().Get()
 	}
 
@@ -117,15 +143,19 @@ func (r *KubeturboReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 				return reconcile.RequeueOnError(err).Get()
 			}
 
+			teardownStart := time.Now()
 			if kt.Spec.Args.CleanupSccImpersonationResources != nil && *kt.Spec.Args.CleanupSccImpersonationResources {
 				//If cleanup scc flag is true, we need to wait for scc resources, pod to delete before removing
 				//service account.
 				r.waitForPodDeletion(ctx, req.NamespacedName, &kt)
 			}
 			//clean up ClusterResources, serviceaccount
-			if err = kubeturbo.Teardown(ctx, r.Client, r.Scheme, &kt); err != nil {
+			if err = kubeturbo.Teardown(ctx, r.Client, r.APIReader, r.Scheme, r.Recorder, &kt); err != nil {
 				return reconcile.RequeueOnError(err).Get()
 			}
+			metrics.TeardownDuration.Observe(time.Since(teardownStart).Seconds())
+			metrics.Forget(&kt)
+			r.event(&kt, corev1.EventTypeNormal, "TeardownComplete", "Cleaned up the cluster resources of kubeturbo")
 		}
 		return reconcile.DoNotRequeue().Get()
 	}
@@ -152,13 +182,44 @@ func (r *KubeturboReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return reconcile.DoNotRequeue().Get()
 	}
 
+	// A paused CR keeps its finalizer handling above, but its resources are left alone
+	paused, err := r.updatePauseState(ctx, &kt)
+	if err != nil {
+		return reconcile.RequeueOnError(err).Get()
+	}
+
+	// Planning only writes the plan ConfigMap, so a paused CR can still preview the changes
+	// it would apply once resumed
+	if plan, _ := strconv.ParseBool(kt.GetAnnotations()[constants.PlanAnnotation]); plan {
+		if err := kubeturbo.Plan(ctx, r.Client, r.APIReader, r.Scheme, r.Recorder, &kt); err != nil {
+			return reconcile.RequeueOnError(err).Get()
+		}
+		return reconcile.DoNotRequeue().Get()
+	}
+
+	if paused {
+		logger.Info(fmt.Sprintf("Reconciliation is paused by the %s annotation", constants.PausedAnnotation))
+		return reconcile.DoNotRequeue().Get()
+	}
+
 	// Only CR that patches with correct finalizer will reach to the reconcile cycle
-	if err := kubeturbo.Reconcile(ctx, r.Client, r.Scheme, &kt); err != nil {
+	if err := kubeturbo.Reconcile(ctx, r.Client, r.APIReader, r.Scheme, r.Recorder, &kt); err != nil {
 		// if race condition happened or on resource deletion, delay the requeue
 		if errors.IsConflict(err) || err == constants.ErrRequeueOnDeletion {
 			logger.Info(fmt.Sprintf("Warning: To avoid race condition, retry reconciliation process in %ds", constants.RequeueDelaySeconds))
 			return reconcile.RequeueAfter(time.Duration(constants.RequeueDelaySeconds * time.Second)).Get()
 		}
+		// e.g. a restart waiting for the configuration changes to settle
+		if requeue, ok := err.(*constants.RequeueAfterError); ok {
+			logger.Info(fmt.Sprintf("%s. Retry in %s", requeue.Reason, requeue.Delay))
+			return reconcile.RequeueAfter(requeue.Delay).Get()
+		}
+		// the CR status tells why, check again later since the cause may be another CR
+		// the block is counted and announced by the reconcile when it first happens
+		if err == constants.ErrReconcileBlocked {
+			logger.Info(fmt.Sprintf("Reconciliation is blocked, check the CR status. Retry in %ds", constants.BlockedRequeueDelaySeconds))
+			return reconcile.RequeueAfter(time.Duration(constants.BlockedRequeueDelaySeconds * time.Second)).Get()
+		}
 		return reconcile.RequeueOnError(err).Get()
 	}
 
@@ -166,6 +227,44 @@ func (r *KubeturboReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return reconcile.DoNotRequeue().Get()
 }
 
+// Reflects the paused annotation in the CR status and announces changes of the pause state
+func (r *KubeturboReconciler) updatePauseState(ctx context.Context, kt *kubeturbosv1.Kubeturbo) (bool, error) {
+	paused, _ := strconv.ParseBool(kt.GetAnnotations()[constants.PausedAnnotation])
+	wasPaused := meta.IsStatusConditionTrue(kt.Status.Conditions, kubeturbosv1.ConditionPaused)
+	if paused == wasPaused {
+		return paused, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               kubeturbosv1.ConditionPaused,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Paused",
+		Message:            fmt.Sprintf("Changes to the kubeturbo resources are paused by the %s annotation", constants.PausedAnnotation),
+		ObservedGeneration: kt.Generation,
+	}
+	if !paused {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Resumed"
+		condition.Message = "Changes to the kubeturbo resources are reconciled"
+	}
+	meta.SetStatusCondition(&kt.Status.Conditions, condition)
+	// the update reads back the stored CR, keep the spec defaults applied to it in memory
+	spec := kt.Spec
+	if err := r.Status().Update(ctx, kt); err != nil {
+		return paused, err
+	}
+	kt.Spec = spec
+	r.event(kt, corev1.EventTypeNormal, condition.Reason, condition.Message)
+	return paused, nil
+}
+
+// Records an event on the CR if the reconciler has a recorder
+func (r *KubeturboReconciler) event(kt *kubeturbosv1.Kubeturbo, eventtype, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(kt, eventtype, reason, message)
+	}
+}
+
 func (r *KubeturboReconciler) waitForPodDeletion(ctx context.Context, namespace types.NamespacedName, kt client.Object) {
 	logger := log.FromContext(ctx)
 	if err := wait.PollUntilContextTimeout(ctx, time.Second, constants.TimeoutInSeconds*time.Second, false, func(ctx context.Context) (bool, error) {
@@ -187,6 +286,7 @@ func (r *KubeturboReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.ServiceAccount{}).
+		Owns(&corev1.Service{}).
 		// won't work for cluster-level resources
 		Complete(r)
 }
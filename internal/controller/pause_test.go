@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	chartsv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Paused Kubeturbo", func() {
+	ctx := context.Background()
+	name := types.NamespacedName{Name: "kubeturbo-release", Namespace: "turbo"}
+
+	BeforeEach(func() {
+		GinkgoT().Setenv(utils.DefaultKubeturboVersionEnvVar, "8.14.3")
+	})
+
+	// A reconciler backed by a fake client holding a CR that already carries the finalizer
+	newReconciler := func(annotations map[string]string) (*KubeturboReconciler, *record.FakeRecorder) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(chartsv1.AddToScheme(scheme)).To(Succeed())
+		cr := &chartsv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name.Name, Namespace: name.Namespace, UID: "1234",
+				Annotations: annotations,
+				Finalizers:  []string{constants.KubeturboFinalizer},
+			},
+			// the fields the API server fills in from the CRD defaults
+			Spec: chartsv1.KubeturboSpec{
+				ServerMeta:         chartsv1.KubeturboServerMeta{TurboServer: "https://Turbo_server_URL"},
+				ServiceAccountName: "turbo-user",
+				RoleName:           chartsv1.RoleTypeClusterAdmin,
+				RoleBinding:        "turbo-all-binding",
+				Image:              chartsv1.KubeturboImage{Repository: "icr.io/cpopen/turbonomic/kubeturbo"},
+				RestAPIConfig:      chartsv1.KubeturboRestAPIConfig{TurbonomicCredentialsSecretName: "turbonomic-credentials"},
+				HANodeConfig:       chartsv1.KubeturboHANodeConfig{NodeRoles: `"master"`},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr).WithStatusSubresource(cr).Build()
+		recorder := record.NewFakeRecorder(100)
+		return &KubeturboReconciler{Client: c, APIReader: c, Scheme: scheme, Recorder: recorder}, recorder
+	}
+
+	It("Leaves the kubeturbo resources alone and reports the pause", func() {
+		r, recorder := newReconciler(map[string]string{constants.PausedAnnotation: "true"})
+
+		Expect(r.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+
+		kt := &chartsv1.Kubeturbo{}
+		Expect(r.Get(ctx, name, kt)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(kt.Status.Conditions, chartsv1.ConditionPaused)).To(BeTrue())
+		Expect(errors.IsNotFound(r.Get(ctx, name, &appsv1.Deployment{}))).To(BeTrue())
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal Paused")))
+	})
+
+	It("Still publishes a plan", func() {
+		r, _ := newReconciler(map[string]string{constants.PausedAnnotation: "true", constants.PlanAnnotation: "true"})
+
+		Expect(r.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+
+		kt := &chartsv1.Kubeturbo{}
+		Expect(r.Get(ctx, name, kt)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(kt.Status.Conditions, chartsv1.ConditionPaused)).To(BeTrue())
+		Expect(r.Get(ctx, types.NamespacedName{Name: "turbo-plan-kubeturbo-release", Namespace: "turbo"}, &corev1.ConfigMap{})).To(Succeed())
+		Expect(errors.IsNotFound(r.Get(ctx, name, &appsv1.Deployment{}))).To(BeTrue())
+	})
+})
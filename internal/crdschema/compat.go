@@ -0,0 +1,117 @@
+package crdschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Checks the CRD installed in the cluster can hold everything the expected CRD defines.
+// The installed CRD must serve every version the expected one serves, store objects in the
+// same version, have no stored versions the expected CRD dropped and keep every field of the
+// expected schemas, as the API server silently prunes the fields its schema lacks
+func Compatible(installed, expected *apiextensionsv1.CustomResourceDefinition) error {
+	var issues []string
+
+	expectedVersions := map[string]bool{}
+	for _, version := range expected.Spec.Versions {
+		expectedVersions[version.Name] = true
+		if !version.Served {
+			continue
+		}
+		installedVersion := findVersion(installed, version.Name)
+		if installedVersion == nil || !installedVersion.Served {
+			issues = append(issues, fmt.Sprintf("version %s is not served", version.Name))
+			continue
+		}
+		if version.Storage && !installedVersion.Storage {
+			issues = append(issues, fmt.Sprintf("version %s is not the storage version", version.Name))
+		}
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		var installedSchema *apiextensionsv1.JSONSchemaProps
+		if installedVersion.Schema != nil {
+			installedSchema = installedVersion.Schema.OpenAPIV3Schema
+		}
+		if missing := MissingFields(installedSchema, version.Schema.OpenAPIV3Schema); len(missing) > 0 {
+			issues = append(issues, fmt.Sprintf("version %s lacks the fields %s", version.Name, strings.Join(missing, ", ")))
+		}
+	}
+	for _, stored := range installed.Status.StoredVersions {
+		if !expectedVersions[stored] {
+			issues = append(issues, fmt.Sprintf("objects are stored in the unsupported version %s", stored))
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%s is not compatible with the operator: %s", installed.Name, strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+// Lists the fields of the expected schema the installed schema lacks, e.g. spec.discovery.
+// Items of arrays are written as name[] and values of maps as name.*. A field isn't missing when
+// the installed schema preserves the unknown fields of its parent
+func MissingFields(installed, expected *apiextensionsv1.JSONSchemaProps) []string {
+	var missing []string
+	missingFields("", installed, expected, &missing)
+	sort.Strings(missing)
+	return missing
+}
+
+func missingFields(path string, installed, expected *apiextensionsv1.JSONSchemaProps, missing *[]string) {
+	if expected == nil {
+		return
+	}
+	if installed == nil {
+		if path != "" {
+			*missing = append(*missing, path)
+		}
+		return
+	}
+	if installed.XPreserveUnknownFields != nil && *installed.XPreserveUnknownFields {
+		return
+	}
+
+	for name, property := range expected.Properties {
+		property := property
+		var installedProperty *apiextensionsv1.JSONSchemaProps
+		if p, found := installed.Properties[name]; found {
+			installedProperty = &p
+		}
+		missingFields(join(path, name), installedProperty, &property, missing)
+	}
+	if expected.Items != nil && expected.Items.Schema != nil {
+		var installedItems *apiextensionsv1.JSONSchemaProps
+		if installed.Items != nil {
+			installedItems = installed.Items.Schema
+		}
+		missingFields(path+"[]", installedItems, expected.Items.Schema, missing)
+	}
+	if expected.AdditionalProperties != nil && expected.AdditionalProperties.Schema != nil {
+		var installedValues *apiextensionsv1.JSONSchemaProps
+		if installed.AdditionalProperties != nil {
+			installedValues = installed.AdditionalProperties.Schema
+		}
+		missingFields(join(path, "*"), installedValues, expected.AdditionalProperties.Schema, missing)
+	}
+}
+
+func findVersion(crd *apiextensionsv1.CustomResourceDefinition, name string) *apiextensionsv1.CustomResourceDefinitionVersion {
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Name == name {
+			return &crd.Spec.Versions[i]
+		}
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,91 @@
+package crdschema_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/config/crd"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/crdschema"
+)
+
+var _ = Describe("Compatible", func() {
+	var expected, installed *apiextensionsv1.CustomResourceDefinition
+
+	BeforeEach(func() {
+		var err error
+		expected, err = crdschema.Parse(crd.Kubeturbo)
+		Expect(err).NotTo(HaveOccurred())
+		installed, err = crdschema.Parse(crd.Kubeturbo)
+		Expect(err).NotTo(HaveOccurred())
+		installed.Status.StoredVersions = []string{"v1"}
+	})
+
+	spec := func(crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.JSONSchemaProps {
+		schema, err := crdschema.VersionSchema(crd, "v1")
+		Expect(err).NotTo(HaveOccurred())
+		property := schema.Properties["spec"]
+		return &property
+	}
+
+	removeSpecField := func(name string) {
+		schema, err := crdschema.VersionSchema(installed, "v1")
+		Expect(err).NotTo(HaveOccurred())
+		property := schema.Properties["spec"]
+		Expect(property.Properties).To(HaveKey(name))
+		delete(property.Properties, name)
+		schema.Properties["spec"] = property
+	}
+
+	It("accepts the CRD the operator is built with", func() {
+		Expect(crdschema.Compatible(installed, expected)).To(Succeed())
+	})
+
+	It("reports the fields a stale CRD lacks", func() {
+		removeSpecField("discovery")
+		removeSpecField("kubeturboPodScheduling")
+
+		err := crdschema.Compatible(installed, expected)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("version v1 lacks the fields spec.discovery, spec.kubeturboPodScheduling"))
+	})
+
+	It("reports nested fields", func() {
+		schema, err := crdschema.VersionSchema(installed, "v1")
+		Expect(err).NotTo(HaveOccurred())
+		specSchema := schema.Properties["spec"]
+		discovery := specSchema.Properties["discovery"]
+		Expect(discovery.Properties).NotTo(BeEmpty())
+		var name string
+		for name = range discovery.Properties {
+			break
+		}
+		delete(discovery.Properties, name)
+		specSchema.Properties["discovery"] = discovery
+		schema.Properties["spec"] = specSchema
+
+		Expect(crdschema.MissingFields(spec(installed), spec(expected))).To(ConsistOf("discovery." + name))
+	})
+
+	It("ignores the fields of objects preserving unknown fields", func() {
+		preserve := true
+		installedSpec := &apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserve}
+		Expect(crdschema.MissingFields(installedSpec, spec(expected))).To(BeEmpty())
+	})
+
+	It("reports a version that isn't served", func() {
+		installed.Spec.Versions = installed.Spec.Versions[:1]
+		Expect(crdschema.Compatible(installed, expected)).To(MatchError(ContainSubstring("version v1alpha1 is not served")))
+	})
+
+	It("reports a different storage version", func() {
+		installed.Spec.Versions[0].Storage = false
+		installed.Spec.Versions[1].Storage = true
+		Expect(crdschema.Compatible(installed, expected)).To(MatchError(ContainSubstring("version v1 is not the storage version")))
+	})
+
+	It("reports stored versions the operator doesn't know", func() {
+		installed.Status.StoredVersions = []string{"v1", "v1beta1"}
+		Expect(crdschema.Compatible(installed, expected)).To(MatchError(ContainSubstring("objects are stored in the unsupported version v1beta1")))
+	})
+})
@@ -0,0 +1,13 @@
+package crdschema_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCrdschema(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crdschema Suite")
+}
@@ -0,0 +1,81 @@
+package crdschema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Parses a CustomResourceDefinition manifest
+func Parse(manifest []byte) (*apiextensionsv1.CustomResourceDefinition, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.UnmarshalStrict(manifest, crd); err != nil {
+		return nil, fmt.Errorf("invalid CustomResourceDefinition: %w", err)
+	}
+	return crd, nil
+}
+
+// Returns the OpenAPI schema of the given version of the CRD
+func VersionSchema(crd *apiextensionsv1.CustomResourceDefinition, version string) (*apiextensionsv1.JSONSchemaProps, error) {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version {
+			if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+				return nil, fmt.Errorf("version %s of %s has no schema", version, crd.Name)
+			}
+			return v.Schema.OpenAPIV3Schema, nil
+		}
+	}
+	return nil, fmt.Errorf("%s doesn't serve version %s", crd.Name, version)
+}
+
+// Fills in the defaults of the schema the same way the API server does when the object is
+// stored: a missing field, or a null field that isn't nullable, gets the default of its schema.
+// obj is the JSON representation of an object, e.g. as produced by yaml.Unmarshal
+func Default(obj map[string]interface{}, schema *apiextensionsv1.JSONSchemaProps) error {
+	return applyDefaults(obj, schema)
+}
+
+// maps and slices are updated in place
+func applyDefaults(value interface{}, schema *apiextensionsv1.JSONSchemaProps) error {
+	if schema == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, property := range schema.Properties {
+			property := property
+			if current, found := v[name]; (!found || current == nil && !property.Nullable) && property.Default != nil {
+				var def interface{}
+				if err := json.Unmarshal(property.Default.Raw, &def); err != nil {
+					return fmt.Errorf("invalid default of %s: %w", name, err)
+				}
+				v[name] = def
+			}
+			if err := applyDefaults(v[name], &property); err != nil {
+				return err
+			}
+		}
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			for name, current := range v {
+				if _, known := schema.Properties[name]; known {
+					continue
+				}
+				if err := applyDefaults(current, schema.AdditionalProperties.Schema); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return nil
+		}
+		for _, item := range v {
+			if err := applyDefaults(item, schema.Items.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package health provides the readiness and liveness checks of the operator
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// The part of the manager's cache the cache check needs
+type CacheSyncer interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// Ready once the informers of the cache have synced. A probe waits for the sync at most the given timeout
+func CacheSynced(c CacheSyncer, timeout time.Duration) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		if !c.WaitForCacheSync(ctx) {
+			return errors.New("the informer caches are not synced")
+		}
+		return nil
+	}
+}
+
+// Ready while the serving certificate of the webhook server in the directory is valid.
+// The operator serves no webhook unless a certificate is mounted, so a missing certificate is fine
+func WebhookCertificate(certDir, certName string) healthz.Checker {
+	path := filepath.Join(certDir, certName)
+	return func(_ *http.Request) error {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read the webhook certificate: %w", err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return fmt.Errorf("%s holds no PEM encoded certificate", path)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("invalid webhook certificate %s: %w", path, err)
+		}
+		if now := time.Now(); now.Before(cert.NotBefore) {
+			return fmt.Errorf("the webhook certificate is not valid before %s", cert.NotBefore)
+		} else if now.After(cert.NotAfter) {
+			return fmt.Errorf("the webhook certificate expired at %s", cert.NotAfter)
+		}
+		return nil
+	}
+}
+
+// Tracks the reconciles in progress to detect a reconcile worker that got stuck
+type ReconcileWatchdog struct {
+	// A reconcile running longer than that is considered stuck
+	Threshold time.Duration
+
+	mu      sync.Mutex
+	next    uint64
+	running map[uint64]time.Time
+}
+
+// Marks the start of a reconcile, the returned function marks its end
+func (w *ReconcileWatchdog) Track() func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running == nil {
+		w.running = map[uint64]time.Time{}
+	}
+	id := w.next
+	w.next++
+	w.running[id] = time.Now()
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.running, id)
+	}
+}
+
+// Healthy unless a reconcile has been running longer than the threshold
+func (w *ReconcileWatchdog) Checker(_ *http.Request) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, started := range w.running {
+		if elapsed := time.Since(started); elapsed > w.Threshold {
+			return fmt.Errorf("a reconcile has been running for %s", elapsed.Round(time.Second))
+		}
+	}
+	return nil
+}
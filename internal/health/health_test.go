@@ -0,0 +1,111 @@
+package health_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/health"
+)
+
+type fakeCache struct {
+	synced bool
+}
+
+func (c *fakeCache) WaitForCacheSync(ctx context.Context) bool {
+	if !c.synced {
+		<-ctx.Done()
+	}
+	return c.synced
+}
+
+var _ = Describe("Health", func() {
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	Describe("CacheSynced", func() {
+		It("is ready once the caches synced", func() {
+			Expect(health.CacheSynced(&fakeCache{synced: true}, time.Second)(req)).To(Succeed())
+		})
+
+		It("is not ready until then", func() {
+			Expect(health.CacheSynced(&fakeCache{}, 10*time.Millisecond)(req)).To(MatchError(ContainSubstring("not synced")))
+		})
+	})
+
+	Describe("WebhookCertificate", func() {
+		var certDir string
+
+		BeforeEach(func() {
+			certDir = GinkgoT().TempDir()
+		})
+
+		writeCert := func(notBefore, notAfter time.Time) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "kubeturbo-operator"},
+				NotBefore:    notBefore,
+				NotAfter:     notAfter,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).NotTo(HaveOccurred())
+			data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+			Expect(os.WriteFile(filepath.Join(certDir, "tls.crt"), data, 0o600)).To(Succeed())
+		}
+
+		It("is ready without a certificate", func() {
+			Expect(health.WebhookCertificate(certDir, "tls.crt")(req)).To(Succeed())
+		})
+
+		It("is ready with a valid certificate", func() {
+			writeCert(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			Expect(health.WebhookCertificate(certDir, "tls.crt")(req)).To(Succeed())
+		})
+
+		It("is not ready with an expired certificate", func() {
+			writeCert(time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+			Expect(health.WebhookCertificate(certDir, "tls.crt")(req)).To(MatchError(ContainSubstring("expired")))
+		})
+
+		It("is not ready with a certificate that isn't valid yet", func() {
+			writeCert(time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+			Expect(health.WebhookCertificate(certDir, "tls.crt")(req)).To(MatchError(ContainSubstring("not valid before")))
+		})
+
+		It("is not ready with a malformed certificate", func() {
+			Expect(os.WriteFile(filepath.Join(certDir, "tls.crt"), []byte("garbage"), 0o600)).To(Succeed())
+			Expect(health.WebhookCertificate(certDir, "tls.crt")(req)).To(MatchError(ContainSubstring("no PEM encoded certificate")))
+		})
+	})
+
+	Describe("ReconcileWatchdog", func() {
+		It("is healthy while the reconciles complete in time", func() {
+			watchdog := &health.ReconcileWatchdog{Threshold: time.Hour}
+			done := watchdog.Track()
+			Expect(watchdog.Checker(req)).To(Succeed())
+			done()
+			Expect(watchdog.Checker(req)).To(Succeed())
+		})
+
+		It("detects a stuck reconcile", func() {
+			watchdog := &health.ReconcileWatchdog{Threshold: 10 * time.Millisecond}
+			done := watchdog.Track()
+			Eventually(func() error { return watchdog.Checker(req) }).Should(MatchError(ContainSubstring("a reconcile has been running")))
+			done()
+			Expect(watchdog.Checker(req)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,218 @@
+// Package helm renders the kubeturbo helm chart and reads the values of its releases, to verify that
+// a Kubeturbo CR deploys kubeturbo the way the chart does. The chart is rendered with text/template,
+// the sprig functions and the helm functions the chart uses, not with the helm engine, so only what
+// `helm template` does for a chart like kubeturbo is covered, see Limitations
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// What Chart.Render leaves out compared with the helm engine
+var Limitations = []string{
+	"dependencies and subcharts are not rendered",
+	"only the files directly in templates/ are rendered",
+	"values.schema.json is not validated",
+	".Capabilities, .Files and .Template are not set, .Release is always an install of revision 1",
+	"lookup never finds an object, like with `helm template`",
+	"of the helm template functions, only include, tpl, required, toYaml, fromYaml and lookup are provided",
+}
+
+// Chart is the subset of a helm chart needed to render its templates
+type Chart struct {
+	Metadata  ChartMetadata
+	Values    map[string]interface{}
+	Templates map[string]string
+}
+
+type ChartMetadata struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion,omitempty"`
+}
+
+// Release describes the helm release the chart is rendered for
+type Release struct {
+	Name      string
+	Namespace string
+}
+
+// Loads Chart.yaml, values.yaml and the templates of the chart at the root of fsys
+func LoadChart(fsys fs.FS) (*Chart, error) {
+	chart := &Chart{Templates: map[string]string{}}
+
+	metadata, err := fs.ReadFile(fsys, "Chart.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(metadata, &chart.Metadata); err != nil {
+		return nil, fmt.Errorf("invalid Chart.yaml: %w", err)
+	}
+
+	values, err := fs.ReadFile(fsys, "values.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(values, &chart.Values); err != nil {
+		return nil, fmt.Errorf("invalid values.yaml: %w", err)
+	}
+
+	templates, err := fs.ReadDir(fsys, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.IsDir() {
+			continue
+		}
+		name := path.Join("templates", t.Name())
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		chart.Templates[name] = string(content)
+	}
+	return chart, nil
+}
+
+// Renders the templates the way `helm template` does and returns the manifests by template name.
+// Partials, i.e. templates starting with an underscore, only provide definitions
+func (c *Chart) Render(values map[string]interface{}, release Release) (map[string]string, error) {
+	root := template.New(c.Metadata.Name).Option("missingkey=zero")
+	root.Funcs(funcMap(root))
+
+	names := make([]string, 0, len(c.Templates))
+	for name := range c.Templates {
+		names = append(names, name)
+	}
+	// partials are parsed first, so that every template can include them
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := isPartial(names[i]), isPartial(names[j])
+		if pi != pj {
+			return pi
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if _, err := root.New(name).Parse(c.Templates[name]); err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", name, err)
+		}
+	}
+
+	top := map[string]interface{}{
+		"Values": CoalesceValues(c.Values, values),
+		"Release": map[string]interface{}{
+			"Name":      release.Name,
+			"Namespace": release.Namespace,
+			"Service":   "Helm",
+			"IsInstall": true,
+			"Revision":  1,
+		},
+		"Chart": map[string]interface{}{
+			"Name":       c.Metadata.Name,
+			"Version":    c.Metadata.Version,
+			"AppVersion": c.Metadata.AppVersion,
+		},
+	}
+
+	manifests := map[string]string{}
+	for _, name := range names {
+		if isPartial(name) {
+			continue
+		}
+		var out bytes.Buffer
+		if err := root.ExecuteTemplate(&out, name, top); err != nil {
+			return nil, fmt.Errorf("unable to render %s: %w", name, err)
+		}
+		// helm drops the placeholder of missing values
+		manifests[name] = strings.ReplaceAll(out.String(), "<no value>", "")
+	}
+	return manifests, nil
+}
+
+func isPartial(name string) bool {
+	return strings.HasPrefix(path.Base(name), "_")
+}
+
+// The sprig functions plus the functions helm adds, without access to the environment
+func funcMap(root *template.Template) template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+
+	funcs["toYaml"] = func(v interface{}) string {
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(string(out), "\n")
+	}
+	funcs["fromYaml"] = func(s string) map[string]interface{} {
+		m := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+			m["Error"] = err.Error()
+		}
+		return m
+	}
+	funcs["include"] = func(name string, data interface{}) (string, error) {
+		var out bytes.Buffer
+		err := root.ExecuteTemplate(&out, name, data)
+		return out.String(), err
+	}
+	funcs["tpl"] = func(text string, data interface{}) (string, error) {
+		t, err := root.Clone()
+		if err != nil {
+			return "", err
+		}
+		if _, err := t.New("tpl").Parse(text); err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		err = t.ExecuteTemplate(&out, "tpl", data)
+		return out.String(), err
+	}
+	funcs["required"] = func(message string, v interface{}) (interface{}, error) {
+		if v == nil || v == "" {
+			return nil, errors.New(message)
+		}
+		return v, nil
+	}
+	// there is no cluster to look up objects in, like with `helm template`
+	funcs["lookup"] = func(apiVersion, kind, namespace, name string) map[string]interface{} {
+		return map[string]interface{}{}
+	}
+	return funcs
+}
+
+// Merges the values into the chart defaults like helm does: maps are merged recursively,
+// other values replace the defaults and a null value removes the default
+func CoalesceValues(defaults, values map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range values {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if src, ok := v.(map[string]interface{}); ok {
+			if dst, ok := merged[k].(map[string]interface{}); ok {
+				merged[k] = CoalesceValues(dst, src)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,78 @@
+package helm_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+)
+
+var _ = Describe("Helm", func() {
+	release := helm.Release{Name: "kubeturbo", Namespace: "turbo"}
+
+	It("Renders the kubeturbo chart", func() {
+		chart, err := helm.LoadChart(os.DirFS("../../deploy/kubeturbo"))
+		Expect(err).NotTo(HaveOccurred())
+
+		manifests, err := chart.Render(map[string]interface{}{
+			"image": map[string]interface{}{"tag": "8.14.3"},
+		}, release)
+		Expect(err).NotTo(HaveOccurred())
+		objs, err := helm.Objects(manifests)
+		Expect(err).NotTo(HaveOccurred())
+
+		var kinds []string
+		for _, obj := range objs {
+			kinds = append(kinds, obj.GetKind())
+		}
+		Expect(kinds).To(ConsistOf("ConfigMap", "ServiceAccount", "ClusterRoleBinding", "Deployment"))
+	})
+
+	It("Merges the values into the chart defaults", func() {
+		defaults := map[string]interface{}{
+			"image":    map[string]interface{}{"repository": "icr.io/cpopen/turbonomic/kubeturbo", "tag": "8.0"},
+			"replicas": 1,
+		}
+		merged := helm.CoalesceValues(defaults, map[string]interface{}{
+			"image":    map[string]interface{}{"tag": "8.14.3"},
+			"replicas": nil,
+		})
+		Expect(merged).To(Equal(map[string]interface{}{
+			"image": map[string]interface{}{"repository": "icr.io/cpopen/turbonomic/kubeturbo", "tag": "8.14.3"},
+		}))
+	})
+
+	It("Reads the values of a release from its Secret", func() {
+		stored, err := json.Marshal(map[string]interface{}{
+			"name":      "kubeturbo",
+			"namespace": "turbo",
+			"config":    map[string]interface{}{"serverMeta": map[string]interface{}{"turboServer": "https://turbo.example.com"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		var compressed bytes.Buffer
+		w := gzip.NewWriter(&compressed)
+		_, err = w.Write(stored)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.kubeturbo.v1", Namespace: "turbo"},
+			Data:       map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(compressed.Bytes()))},
+		}
+		r, values, err := helm.ReleaseFromSecret(secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r).To(Equal(release))
+		Expect(values).To(HaveKeyWithValue("serverMeta", HaveKeyWithValue("turboServer", "https://turbo.example.com")))
+
+		_, _, err = helm.ReleaseFromSecret(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
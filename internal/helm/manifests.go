@@ -0,0 +1,80 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// helm stores a release in this key of its Secret
+const releaseKey = "release"
+
+// Decodes the rendered manifests into objects, in the order of the template names
+func Objects(manifests map[string]string) ([]*unstructured.Unstructured, error) {
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var objs []*unstructured.Unstructured
+	for _, name := range names {
+		decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifests[name]), 4096)
+		for {
+			obj := map[string]interface{}{}
+			if err := decoder.Decode(&obj); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("invalid manifest in %s: %w", name, err)
+			}
+			// documents with only comments or conditional content that was left out
+			if len(obj) == 0 {
+				continue
+			}
+			objs = append(objs, &unstructured.Unstructured{Object: obj})
+		}
+	}
+	return objs, nil
+}
+
+// Reads the release name, namespace and the values supplied by the user from the Secret
+// helm stores a release in, e.g. sh.helm.release.v1.kubeturbo.v1
+func ReleaseFromSecret(secret *corev1.Secret) (Release, map[string]interface{}, error) {
+	encoded, found := secret.Data[releaseKey]
+	if !found {
+		return Release{}, nil, fmt.Errorf("%s is not a helm release, it has no %s key", secret.Name, releaseKey)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return Release{}, nil, fmt.Errorf("invalid helm release %s: %w", secret.Name, err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return Release{}, nil, fmt.Errorf("invalid helm release %s: %w", secret.Name, err)
+	}
+	defer reader.Close()
+
+	var release struct {
+		Name      string                 `json:"name"`
+		Namespace string                 `json:"namespace"`
+		Config    map[string]interface{} `json:"config"`
+	}
+	if err := json.NewDecoder(reader).Decode(&release); err != nil {
+		return Release{}, nil, fmt.Errorf("invalid helm release %s: %w", secret.Name, err)
+	}
+	if release.Config == nil {
+		release.Config = map[string]interface{}{}
+	}
+	return Release{Name: release.Name, Namespace: release.Namespace}, release.Config, nil
+}
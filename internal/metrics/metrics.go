@@ -0,0 +1,139 @@
+// Package metrics defines the Prometheus metrics of the operator, served along with the
+// controller-runtime metrics by the metrics server of the manager
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+)
+
+const namespace = "kubeturbo_operator"
+
+var (
+	// Restarts of kubeturbo to pick up a change of its configuration
+	ConfigRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_restarts_total",
+		Help:      "Number of kubeturbo restarts triggered by a configuration change",
+	}, []string{"namespace", "name"})
+
+	// Reconciles stopped because the CR is invalid or blocked, see the CR status for the cause
+	ValidationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_failures_total",
+		Help:      "Number of reconciles stopped because the Kubeturbo CR is invalid or blocked",
+	}, []string{"namespace", "name", "reason"})
+
+	// Time to clean up the resources of a deleted CR
+	TeardownDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "teardown_duration_seconds",
+		Help:      "Time taken to clean up the resources of a deleted Kubeturbo CR",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+
+	// Latency of the steps of a reconcile
+	ReconcileStepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_step_duration_seconds",
+		Help:      "Time taken by each step of the Kubeturbo reconcile",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"step"})
+
+	// Steps of a reconcile that returned an error
+	ReconcileStepErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_step_errors_total",
+		Help:      "Number of errors returned by each step of the Kubeturbo reconcile",
+	}, []string{"step"})
+
+	// The kubeturbo version deployed for each CR, always 1
+	KubeturboVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kubeturbo_version_info",
+		Help:      "The kubeturbo image version deployed for each Kubeturbo CR",
+	}, []string{"namespace", "name", "version"})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		ConfigRestarts,
+		ValidationFailures,
+		TeardownDuration,
+		ReconcileStepDuration,
+		ReconcileStepErrors,
+		KubeturboVersion,
+	)
+}
+
+// Wraps a step of the reconcile to record its latency and whether it failed
+func Step(step string, fn func() error) func() error {
+	return func() error {
+		start := time.Now()
+		err := fn()
+		ReconcileStepDuration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+		if err != nil {
+			ReconcileStepErrors.WithLabelValues(step).Inc()
+		}
+		return err
+	}
+}
+
+// Records the kubeturbo version deployed for the CR, replacing the version deployed before
+func SetKubeturboVersion(kt *kubeturbosv1.Kubeturbo, version string) {
+	KubeturboVersion.DeletePartialMatch(prometheus.Labels{"namespace": kt.Namespace, "name": kt.Name})
+	KubeturboVersion.WithLabelValues(kt.Namespace, kt.Name, version).Set(1)
+}
+
+// Drops the metrics of a deleted CR
+func Forget(kt *kubeturbosv1.Kubeturbo) {
+	labels := prometheus.Labels{"namespace": kt.Namespace, "name": kt.Name}
+	KubeturboVersion.DeletePartialMatch(labels)
+	ConfigRestarts.DeletePartialMatch(labels)
+	ValidationFailures.DeletePartialMatch(labels)
+}
+
+var managedKubeturbosDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "managed_kubeturbos"),
+	"Number of Kubeturbo CRs by the status of their conditions",
+	[]string{"condition", "status"}, nil,
+)
+
+// Counts the Kubeturbo CRs by condition when the metrics are scraped
+type KubeturboCollector struct {
+	Reader client.Reader
+	// How long a scrape waits for the CRs
+	Timeout time.Duration
+}
+
+func (c *KubeturboCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- managedKubeturbosDesc
+}
+
+func (c *KubeturboCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	kts := &kubeturbosv1.KubeturboList{}
+	if err := c.Reader.List(ctx, kts); err != nil {
+		ch <- prometheus.NewInvalidMetric(managedKubeturbosDesc, err)
+		return
+	}
+
+	type key struct{ condition, status string }
+	counts := map[key]int{}
+	for _, kt := range kts.Items {
+		for _, condition := range kt.Status.Conditions {
+			counts[key{condition.Type, string(condition.Status)}]++
+		}
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(managedKubeturbosDesc, prometheus.GaugeValue, float64(count), k.condition, k.status)
+	}
+}
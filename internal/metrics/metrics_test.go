@@ -0,0 +1,81 @@
+package metrics_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/metrics"
+)
+
+var _ = Describe("Metrics", func() {
+	newKubeturbo := func(name string, conditions ...metav1.Condition) *kubeturbosv1.Kubeturbo {
+		return &kubeturbosv1.Kubeturbo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "turbo"},
+			Status:     kubeturbosv1.KubeturboStatus{Conditions: conditions},
+		}
+	}
+
+	Describe("Step", func() {
+		It("records the latency and errors of a step", func() {
+			ok := metrics.Step("okStep", func() error { return nil })
+			failing := metrics.Step("failingStep", func() error { return errors.New("boom") })
+
+			Expect(ok()).To(Succeed())
+			Expect(failing()).To(MatchError("boom"))
+
+			Expect(testutil.CollectAndCount(metrics.ReconcileStepDuration)).To(BeNumerically(">=", 2))
+			Expect(testutil.ToFloat64(metrics.ReconcileStepErrors.WithLabelValues("okStep"))).To(BeZero())
+			Expect(testutil.ToFloat64(metrics.ReconcileStepErrors.WithLabelValues("failingStep"))).To(Equal(1.0))
+		})
+	})
+
+	Describe("SetKubeturboVersion", func() {
+		It("keeps only the version deployed last", func() {
+			kt := newKubeturbo("versioned")
+			metrics.SetKubeturboVersion(kt, "8.14.3")
+			metrics.SetKubeturboVersion(kt, "8.14.4")
+
+			Expect(testutil.CollectAndCompare(metrics.KubeturboVersion, strings.NewReader(`
+# HELP kubeturbo_operator_kubeturbo_version_info The kubeturbo image version deployed for each Kubeturbo CR
+# TYPE kubeturbo_operator_kubeturbo_version_info gauge
+kubeturbo_operator_kubeturbo_version_info{name="versioned",namespace="turbo",version="8.14.4"} 1
+`))).To(Succeed())
+
+			metrics.Forget(kt)
+			Expect(testutil.CollectAndCount(metrics.KubeturboVersion)).To(BeZero())
+		})
+	})
+
+	Describe("KubeturboCollector", func() {
+		It("counts the CRs by condition", func() {
+			scheme := runtime.NewScheme()
+			Expect(kubeturbosv1.AddToScheme(scheme)).To(Succeed())
+			reachable := metav1.Condition{Type: kubeturbosv1.ConditionServerReachable, Status: metav1.ConditionTrue}
+			unreachable := metav1.Condition{Type: kubeturbosv1.ConditionServerReachable, Status: metav1.ConditionFalse}
+			conflicted := metav1.Condition{Type: kubeturbosv1.ConditionConflicted, Status: metav1.ConditionTrue}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newKubeturbo("a", reachable),
+				newKubeturbo("b", reachable, conflicted),
+				newKubeturbo("c", unreachable),
+			).Build()
+
+			collector := &metrics.KubeturboCollector{Reader: c, Timeout: time.Second}
+			Expect(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP kubeturbo_operator_managed_kubeturbos Number of Kubeturbo CRs by the status of their conditions
+# TYPE kubeturbo_operator_managed_kubeturbos gauge
+kubeturbo_operator_managed_kubeturbos{condition="Conflicted",status="True"} 1
+kubeturbo_operator_managed_kubeturbos{condition="ServerReachable",status="False"} 1
+kubeturbo_operator_managed_kubeturbos{condition="ServerReachable",status="True"} 2
+`))).To(Succeed())
+		})
+	})
+})
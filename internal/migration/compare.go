@@ -0,0 +1,260 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Flags kubeturbo falls back to when they are not passed. The chart leaves them out
+// while the operator always passes them, which doesn't change the behavior
+var kubeturboFlagDefaults = map[string]string{
+	"--stitch-uuid":                               "true",
+	"--cleanup-scc-impersonation-resources":       "true",
+	"--skip-creating-scc-impersonation-resources": "false",
+	"--discovery-interval-sec":                    "600",
+	"--discovery-sample-interval":                 "60",
+	"--discovery-samples":                         "10",
+	"--discovery-timeout-sec":                     "180",
+	"--garbage-collection-interval":               "10",
+	"--discovery-workers":                         "10",
+	"--port":                                      "10265",
+}
+
+// The parts of the objects that determine how kubeturbo behaves, by kind. Metadata such as
+// labels and the operator additions like health probes are left out on purpose
+var comparedFields = map[string][][]string{
+	"ConfigMap":      {{"data"}},
+	"ServiceAccount": {{"metadata", "name"}},
+	"ClusterRole":    {{"metadata", "name"}, {"rules"}},
+	"ClusterRoleBinding": {
+		{"metadata", "name"}, {"roleRef"}, {"subjects"},
+	},
+	"Deployment": {
+		{"spec", "replicas"},
+		{"spec", "template", "metadata", "annotations"},
+		{"spec", "template", "spec", "serviceAccountName"},
+		{"spec", "template", "spec", "imagePullSecrets"},
+		{"spec", "template", "spec", "nodeSelector"},
+		{"spec", "template", "spec", "affinity"},
+		{"spec", "template", "spec", "tolerations"},
+		{"spec", "template", "spec", "containers", "0", "image"},
+		{"spec", "template", "spec", "containers", "0", "imagePullPolicy"},
+		{"spec", "template", "spec", "containers", "0", "args"},
+		{"spec", "template", "spec", "containers", "0", "resources"},
+	},
+}
+
+// Compares what the helm chart and the operator deploy and returns the differences,
+// e.g. `Deployment spec.template.spec.containers.0.image: helm "a:1" operator "a:2"`
+func Compare(helmObjs, operatorObjs []*unstructured.Unstructured) []string {
+	helmByKind, operatorByKind := byKind(helmObjs), byKind(operatorObjs)
+	kinds := make([]string, 0, len(comparedFields))
+	for kind := range comparedFields {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var differences []string
+	for _, kind := range kinds {
+		h, o := helmByKind[kind], operatorByKind[kind]
+		switch {
+		case h == nil && o == nil:
+			continue
+		case h == nil:
+			differences = append(differences, fmt.Sprintf("%s %s: only deployed by the operator", kind, o.GetName()))
+			continue
+		case o == nil:
+			differences = append(differences, fmt.Sprintf("%s %s: only deployed by helm", kind, h.GetName()))
+			continue
+		}
+		for _, path := range comparedFields[kind] {
+			hv, ov := normalize(kind, path, lookup(h.Object, path)), normalize(kind, path, lookup(o.Object, path))
+			diffValues(kind+" "+strings.Join(path, "."), hv, ov, &differences)
+		}
+	}
+	return differences
+}
+
+func byKind(objs []*unstructured.Unstructured) map[string]*unstructured.Unstructured {
+	m := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		m[obj.GetKind()] = obj
+	}
+	return m
+}
+
+// Walks maps by key and lists by index
+func lookup(obj interface{}, path []string) interface{} {
+	for _, p := range path {
+		switch v := obj.(type) {
+		case map[string]interface{}:
+			obj = v[p]
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscanf(p, "%d", &i); err != nil || i >= len(v) {
+				return nil
+			}
+			obj = v[i]
+		default:
+			return nil
+		}
+	}
+	return obj
+}
+
+// Brings both sides to a common form: the JSON config files are parsed, args are sorted
+// and stripped of kubeturbo defaults, numbers are compared as floats and empty values are dropped
+func normalize(kind string, path []string, v interface{}) interface{} {
+	if kind == "ConfigMap" {
+		if data, ok := v.(map[string]interface{}); ok {
+			parsed := map[string]interface{}{}
+			for k, raw := range data {
+				var config interface{}
+				if s, ok := raw.(string); ok && json.Unmarshal([]byte(s), &config) == nil {
+					parsed[k] = config
+				} else {
+					parsed[k] = raw
+				}
+			}
+			v = parsed
+		}
+	}
+	if path[len(path)-1] == "args" {
+		if list, ok := v.([]interface{}); ok {
+			args := make([]interface{}, 0, len(list))
+			for _, arg := range list {
+				s := fmt.Sprint(arg)
+				flag, value, _ := strings.Cut(s, "=")
+				if def, found := kubeturboFlagDefaults[flag]; found && def == value {
+					continue
+				}
+				args = append(args, s)
+			}
+			sort.Slice(args, func(i, j int) bool { return args[i].(string) < args[j].(string) })
+			v = args
+		}
+	}
+	if path[len(path)-1] == "rules" {
+		v = normalizeRules(v)
+	}
+	return dropEmpty(v)
+}
+
+// The order of the API groups, resources and verbs of a rule doesn't matter, and
+// a YAML list item left empty stands for the core API group
+func normalizeRules(v interface{}) interface{} {
+	rules, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+	normalized := make([]interface{}, 0, len(rules))
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			normalized = append(normalized, r)
+			continue
+		}
+		sorted := map[string]interface{}{}
+		for k, field := range rule {
+			list, ok := field.([]interface{})
+			if !ok {
+				sorted[k] = field
+				continue
+			}
+			items := make([]string, 0, len(list))
+			for _, item := range list {
+				if item == nil {
+					item = ""
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			sort.Strings(items)
+			values := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				values = append(values, item)
+			}
+			sorted[k] = values
+		}
+		normalized = append(normalized, sorted)
+	}
+	return normalized
+}
+
+func dropEmpty(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, item := range value {
+			if item = dropEmpty(item); item != nil {
+				m[k] = item
+			}
+		}
+		if len(m) == 0 {
+			return nil
+		}
+		return m
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		list := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			list = append(list, dropEmpty(item))
+		}
+		return list
+	case string:
+		if value == "" {
+			return nil
+		}
+	case int64:
+		return float64(value)
+	case int32:
+		return float64(value)
+	case int:
+		return float64(value)
+	}
+	return v
+}
+
+func diffValues(path string, helm, operator interface{}, differences *[]string) {
+	if reflect.DeepEqual(helm, operator) {
+		return
+	}
+	hm, hok := helm.(map[string]interface{})
+	om, ook := operator.(map[string]interface{})
+	if !hok || !ook {
+		*differences = append(*differences, fmt.Sprintf("%s: helm %s operator %s", path, toJSON(helm), toJSON(operator)))
+		return
+	}
+	keys := map[string]bool{}
+	for k := range hm {
+		keys[k] = true
+	}
+	for k := range om {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		diffValues(path+"."+k, hm[k], om[k], differences)
+	}
+}
+
+func toJSON(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
@@ -0,0 +1,115 @@
+package migration_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/migration"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Migration", func() {
+	release := helm.Release{Name: "kubeturbo", Namespace: "turbo"}
+
+	BeforeEach(func() {
+		GinkgoT().Setenv(utils.DefaultKubeturboVersionEnvVar, "8.14.3")
+	})
+
+	It("Converts helm values into a Kubeturbo CR", func() {
+		conversion, err := migration.FromHelmValues(map[string]interface{}{
+			"serverMeta":   map[string]interface{}{"turboServer": "https://turbo.example.com", "version": "8.14.3"},
+			"targetConfig": map[string]interface{}{"targetName": "prod", "targetType": "Kubernetes"},
+			"args":         map[string]interface{}{"logginglevel": 4},
+			"nameOverride": "turbo",
+			"annotations":  map[string]interface{}{"example.com/team": "infra"},
+			"roleName":     nil,
+		}, release)
+		Expect(err).NotTo(HaveOccurred())
+
+		kt := conversion.Kubeturbo
+		field := func(fields ...string) string {
+			v, _, err := unstructured.NestedString(kt.Object, fields...)
+			Expect(err).NotTo(HaveOccurred())
+			return v
+		}
+		Expect(kt.GetKind()).To(Equal("Kubeturbo"))
+		Expect(kt.GetName()).To(Equal("kubeturbo"))
+		Expect(kt.GetNamespace()).To(Equal("turbo"))
+		Expect(field("spec", "targetConfig", "targetName")).To(Equal("prod"))
+		Expect(kt.Object["spec"]).To(HaveKeyWithValue("args", HaveKeyWithValue("logginglevel", 4)))
+		// free-form maps are carried over as a whole
+		Expect(field("spec", "annotations", "example.com/team")).To(Equal("infra"))
+		Expect(kt.Object["spec"]).NotTo(HaveKey("roleName"))
+		Expect(conversion.Unmapped).To(Equal([]string{"nameOverride", "targetConfig.targetType"}))
+	})
+
+	It("Rejects values that don't fit the CR", func() {
+		_, err := migration.FromHelmValues(map[string]interface{}{
+			"targetConfig": map[string]interface{}{"targetName": map[string]interface{}{"nested": true}},
+		}, release)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Compares the resources deployed by helm and the operator", func() {
+		deployment := func(image string, args ...interface{}) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"kind": "Deployment",
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"image": image, "args": args}},
+				}}},
+			}}
+		}
+		configMap := func(config string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"kind": "ConfigMap",
+				"data": map[string]interface{}{"turbo.config": config},
+			}}
+		}
+
+		// args are compared regardless of their order and kubeturbo defaults
+		Expect(migration.Compare(
+			[]*unstructured.Unstructured{deployment("kubeturbo:8.14.3", "--v=2", "--kubelet-https=true"), configMap(`{"a": 1, "b": ""}`)},
+			[]*unstructured.Unstructured{deployment("kubeturbo:8.14.3", "--kubelet-https=true", "--stitch-uuid=true", "--v=2"), configMap(`{"a":1}`)},
+		)).To(BeEmpty())
+
+		Expect(migration.Compare(
+			[]*unstructured.Unstructured{deployment("kubeturbo:8.14.2"), configMap(`{"a": {"b": 1}}`)},
+			[]*unstructured.Unstructured{deployment("kubeturbo:8.14.3")},
+		)).To(Equal([]string{
+			"ConfigMap : only deployed by helm",
+			`Deployment spec.template.spec.containers.0.image: helm "kubeturbo:8.14.2" operator "kubeturbo:8.14.3"`,
+		}))
+	})
+
+	It("Verifies the conversion against the kubeturbo chart", func() {
+		chart, err := helm.LoadChart(os.DirFS("../../deploy/kubeturbo"))
+		Expect(err).NotTo(HaveOccurred())
+
+		values := map[string]interface{}{
+			"serverMeta":   map[string]interface{}{"turboServer": "https://turbo.example.com", "version": "8.14.3"},
+			"image":        map[string]interface{}{"tag": "8.14.3"},
+			"targetConfig": map[string]interface{}{"targetName": "prod"},
+		}
+		conversion, err := migration.FromHelmValues(values, release)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conversion.Unmapped).To(BeEmpty())
+
+		differences, err := migration.Verify(chart, values, release, conversion)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(differences).To(ConsistOf(
+			// the chart leaves both to the defaults while the operator sets them
+			HavePrefix("ConfigMap data.turbo-autoreload.config.discovery:"),
+			HavePrefix("Deployment spec.replicas:"),
+			// the keys the operator spells differently, see testdata/parity_allowlist.yaml
+			HavePrefix("ConfigMap data.turbo-autoreload.config.logging:"),
+			HavePrefix("ConfigMap data.turbo-autoreload.config.nodePoolSize.mac:"),
+			HavePrefix("ConfigMap data.turbo-autoreload.config.nodePoolSize.max:"),
+			HavePrefix("ConfigMap data.turbo.config.HANodeConfig.nodeRoles:"),
+			HavePrefix("ConfigMap data.turbo.config.HANodeConfig.roles:"),
+		))
+	})
+})
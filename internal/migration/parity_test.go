@@ -0,0 +1,94 @@
+package migration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/migration"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+// An intended difference between the chart and the operator, see testdata/parity_allowlist.yaml
+type allowedDifference struct {
+	Path   string `json:"path"`
+	Values string `json:"values,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Renders the helm chart and the operator resources from the same values and fails on the
+// differences that are not in the allowlist
+var _ = Describe("Parity of the helm chart and the operator", Ordered, func() {
+	release := helm.Release{Name: "kubeturbo", Namespace: "turbo"}
+	valuesFiles, err := filepath.Glob("testdata/parity/*.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	var chart *helm.Chart
+	var allowlist []allowedDifference
+	matched := map[int]bool{}
+
+	BeforeAll(func() {
+		var err error
+		chart, err = helm.LoadChart(os.DirFS("../../deploy/kubeturbo"))
+		Expect(err).NotTo(HaveOccurred())
+
+		manifest, err := os.ReadFile("testdata/parity_allowlist.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(yaml.Unmarshal(manifest, &allowlist)).To(Succeed())
+		for _, allowed := range allowlist {
+			Expect(allowed.Reason).NotTo(BeEmpty(), "the allowlist entry for %s must tell why", allowed.Path)
+		}
+	})
+
+	BeforeEach(func() {
+		GinkgoT().Setenv(utils.DefaultKubeturboVersionEnvVar, "8.14.3")
+	})
+
+	isAllowed := func(valuesFile, difference string) bool {
+		path, _, _ := strings.Cut(difference, ":")
+		for i, allowed := range allowlist {
+			if allowed.Path == path && (allowed.Values == "" || allowed.Values == valuesFile) {
+				matched[i] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, valuesFile := range valuesFiles {
+		valuesFile := valuesFile
+		It("Deploys kubeturbo the same way from "+filepath.Base(valuesFile), func() {
+			manifest, err := os.ReadFile(valuesFile)
+			Expect(err).NotTo(HaveOccurred())
+			values := map[string]interface{}{}
+			Expect(yaml.Unmarshal(manifest, &values)).To(Succeed())
+
+			conversion, err := migration.FromHelmValues(values, release)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conversion.Unmapped).To(BeEmpty(), "the values should only use settings the CR supports")
+
+			differences, err := migration.Verify(chart, values, release, conversion)
+			Expect(err).NotTo(HaveOccurred())
+			var unexpected []string
+			for _, difference := range differences {
+				if !isAllowed(filepath.Base(valuesFile), difference) {
+					unexpected = append(unexpected, difference)
+				}
+			}
+			Expect(unexpected).To(BeEmpty(), "fix the drift or document it in testdata/parity_allowlist.yaml")
+		})
+	}
+
+	It("Has no stale allowlist entries", func() {
+		for i, allowed := range allowlist {
+			Expect(matched).To(HaveKey(i), "%s doesn't differ anymore, remove it from the allowlist", allowed.Path)
+		}
+	})
+})
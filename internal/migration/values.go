@@ -0,0 +1,106 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/config/crd"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/crdschema"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+)
+
+// Conversion is a Kubeturbo CR equivalent to the values of a helm release
+type Conversion struct {
+	// The CR holding every value that has an equivalent in the CR spec
+	Kubeturbo *unstructured.Unstructured
+	// Paths of the values without an equivalent in the CR spec, e.g. targetConfig.targetType
+	Unmapped []string
+}
+
+// Converts the values supplied to the kubeturbo helm chart into a Kubeturbo CR named after the release.
+// The chart values and the CR spec share their layout, values are carried over as long as the CRD
+// schema knows them. Values left to the chart defaults are left to the CRD defaults as well
+func FromHelmValues(values map[string]interface{}, release helm.Release) (*Conversion, error) {
+	kubeturboCRD, err := crdschema.Parse(crd.Kubeturbo)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := crdschema.VersionSchema(kubeturboCRD, kubeturbosv1.GroupVersion.Version)
+	if err != nil {
+		return nil, err
+	}
+	specSchema, found := schema.Properties["spec"]
+	if !found {
+		return nil, fmt.Errorf("the schema of %s has no spec", kubeturboCRD.Name)
+	}
+
+	conversion := &Conversion{}
+	spec := mapValues(values, &specSchema, "", &conversion.Unmapped)
+
+	kt := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	kt.SetAPIVersion(kubeturbosv1.GroupVersion.String())
+	kt.SetKind("Kubeturbo")
+	kt.SetName(release.Name)
+	kt.SetNamespace(release.Namespace)
+
+	// catches values of the wrong type, e.g. a number where the CR expects a string
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(kt.Object, &kubeturbosv1.Kubeturbo{}); err != nil {
+		return nil, fmt.Errorf("the values don't fit the Kubeturbo CR: %w", err)
+	}
+	conversion.Kubeturbo = kt
+	return conversion, nil
+}
+
+// Copies the values the schema knows, and records the paths of the others
+func mapValues(values map[string]interface{}, schema *apiextensionsv1.JSONSchemaProps, prefix string, unmapped *[]string) map[string]interface{} {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mapped := map[string]interface{}{}
+	for _, k := range keys {
+		v := values[k]
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		// helm treats null like an unset value
+		if v == nil {
+			continue
+		}
+
+		property, known := schema.Properties[k]
+		if !known {
+			if acceptsAnyKey(schema) {
+				mapped[k] = v
+			} else {
+				*unmapped = append(*unmapped, path)
+			}
+			continue
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok && len(property.Properties) > 0 {
+			if m := mapValues(nested, &property, path, unmapped); len(m) > 0 {
+				mapped[k] = m
+			}
+			continue
+		}
+		mapped[k] = v
+	}
+	return mapped
+}
+
+func acceptsAnyKey(schema *apiextensionsv1.JSONSchemaProps) bool {
+	if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+		return true
+	}
+	additional := schema.AdditionalProperties
+	return additional != nil && (additional.Allows || additional.Schema != nil)
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/helm"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/render"
+)
+
+// Renders the chart with the values and the operator resources of the converted CR,
+// and returns how they differ. No differences prove the conversion equivalent
+func Verify(chart *helm.Chart, values map[string]interface{}, release helm.Release, conversion *Conversion) ([]string, error) {
+	manifests, err := chart.Render(values, release)
+	if err != nil {
+		return nil, err
+	}
+	helmObjs, err := helm.Objects(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	operatorObjs, err := renderOperator(conversion.Kubeturbo)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(helmObjs, operatorObjs), nil
+}
+
+func renderOperator(cr *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	manifest, err := yaml.Marshal(cr.Object)
+	if err != nil {
+		return nil, err
+	}
+	kt, err := render.Decode(manifest)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := render.Objects(context.Background(), kt, render.PlaceholderClusterUID)
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, &unstructured.Unstructured{Object: u})
+	}
+	return converted, nil
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	kubeturbosv1 "github.ibm.com/turbonomic/kubeturbo-deploy/api/v1"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/config/crd"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/api/kubeturbo"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/crdschema"
+)
+
+// Stands in for the UID of the kube-system namespace when no cluster is at hand
+const PlaceholderClusterUID = "00000000-0000-0000-0000-000000000000"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kubeturbosv1.AddToScheme(scheme))
+}
+
+// Decodes a Kubeturbo CR manifest and fills in the defaults the API server and the operator would
+func Decode(manifest []byte) (*kubeturbosv1.Kubeturbo, error) {
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal(manifest, &obj); err != nil {
+		return nil, fmt.Errorf("invalid Kubeturbo CR: %w", err)
+	}
+	gvk := kubeturbosv1.GroupVersion.WithKind("Kubeturbo")
+	if obj["apiVersion"] != gvk.GroupVersion().String() || obj["kind"] != gvk.Kind {
+		return nil, fmt.Errorf("expected a %s %s, got a %v %v", gvk.GroupVersion(), gvk.Kind, obj["apiVersion"], obj["kind"])
+	}
+
+	kubeturboCRD, err := crdschema.Parse(crd.Kubeturbo)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := crdschema.VersionSchema(kubeturboCRD, gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := crdschema.Default(obj, schema); err != nil {
+		return nil, err
+	}
+
+	kt := &kubeturbosv1.Kubeturbo{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, kt); err != nil {
+		return nil, fmt.Errorf("invalid Kubeturbo CR: %w", err)
+	}
+	if kt.Namespace == "" {
+		kt.Namespace = metav1.NamespaceDefault
+	}
+	if err := kt.SetSpecDefault(); err != nil {
+		return nil, err
+	}
+	return kt, nil
+}
+
+// Returns the objects the operator creates for the CR in a cluster whose kube-system namespace has the given UID
+func Objects(ctx context.Context, kt *kubeturbosv1.Kubeturbo, clusterUID types.UID) ([]client.Object, error) {
+	kubeSystem := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem, UID: clusterUID}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kubeSystem).Build()
+	return kubeturbo.Render(ctx, c, scheme, kt)
+}
+
+// Serializes the objects as a multi-document YAML stream
+func YAML(objs []client.Object) ([]byte, error) {
+	var out bytes.Buffer
+	for _, obj := range objs {
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(doc)
+	}
+	return out.Bytes(), nil
+}
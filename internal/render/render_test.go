@@ -0,0 +1,97 @@
+package render_test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/render"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/utils"
+)
+
+var _ = Describe("Render", func() {
+	BeforeEach(func() {
+		GinkgoT().Setenv(utils.DefaultKubeturboVersionEnvVar, "8.14.3")
+	})
+
+	kinds := func(objs []client.Object) []string {
+		var kinds []string
+		for _, obj := range objs {
+			kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		return kinds
+	}
+
+	It("Renders the resources of the sample CR", func() {
+		manifest, err := os.ReadFile("../../config/samples/charts_v1_kubeturbo.yaml")
+		Expect(err).NotTo(HaveOccurred())
+
+		kt, err := render.Decode(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		// defaults of the CRD schema
+		Expect(kt.Spec.ServiceAccountName).To(Equal("turbo-user"))
+		Expect(kt.Spec.RoleBinding).To(Equal("turbo-all-binding"))
+
+		objs, err := render.Objects(context.Background(), kt, render.PlaceholderClusterUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds(objs)).To(Equal([]string{"ConfigMap", "ServiceAccount", "ClusterRoleBinding", "Deployment"}))
+
+		dep := objs[3].(*appsv1.Deployment)
+		Expect(dep.Spec.Template.Spec.Containers[0].Image).To(Equal("icr.io/cpopen/turbonomic/kubeturbo:<Turbo_version>"))
+
+		out, err := render.YAML(objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(HavePrefix("---\napiVersion: v1\n"))
+	})
+
+	It("Renders an operator generated ClusterRole and a derived target name", func() {
+		kt, err := render.Decode([]byte(`
+apiVersion: charts.helm.k8s.io/v1
+kind: Kubeturbo
+metadata:
+  name: kubeturbo-release
+spec:
+  roleName: turbo-cluster-reader
+  targetConfig:
+    deriveTargetName: true
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kt.Namespace).To(Equal("default"))
+
+		objs, err := render.Objects(context.Background(), kt, "6f1c2d3e-aaaa-bbbb-cccc-0123456789ab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds(objs)).To(Equal([]string{"ConfigMap", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Deployment"}))
+		Expect(objs[0].(*corev1.ConfigMap).Data["turbo.config"]).To(ContainSubstring(`"targetName": "cluster-6f1c2d3e"`))
+	})
+
+	It("Renders the metrics objects and the reader role of an action freeze", func() {
+		kt, err := render.Decode([]byte(`
+apiVersion: charts.helm.k8s.io/v1
+kind: Kubeturbo
+metadata:
+  name: kubeturbo-release
+spec:
+  metrics:
+    enabled: true
+  actionFreeze:
+    enabled: true
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		objs, err := render.Objects(context.Background(), kt, render.PlaceholderClusterUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds(objs)).To(Equal([]string{"ConfigMap", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Deployment", "Service", "ServiceMonitor"}))
+		Expect(objs[3].(*rbacv1.ClusterRoleBinding).RoleRef.Name).To(Equal("turbo-cluster-reader-kubeturbo-release-default"))
+	})
+
+	It("Rejects other kinds", func() {
+		_, err := render.Decode([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+		Expect(err).To(MatchError(ContainSubstring("expected a charts.helm.k8s.io/v1 Kubeturbo")))
+	})
+})
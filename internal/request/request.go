@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/tracing"
 )
 
 type BaseRequest[T client.Object] struct {
@@ -18,6 +25,8 @@ type BaseRequest[T client.Object] struct {
 	Client  client.Client
 	Context context.Context
 	Scheme  *runtime.Scheme
+	// Records the changes of the child objects as events of the CR, no events if nil
+	Recorder record.EventRecorder
 }
 
 func (r *BaseRequest[T]) Namespace() string {
@@ -42,37 +51,150 @@ func (r *BaseRequest[T]) SetControllerReference(obj metav1.Object) {
 	ctrl.SetControllerReference(r.Cr, obj, r.Scheme)
 }
 
-func (r *BaseRequest[T]) CreateOrUpdate(obj client.Object, fn controllerutil.MutateFn) (controllerutil.OperationResult, error) {
-	return controllerutil.CreateOrUpdate(r.Context, r.Client, obj, fn)
+func (r *BaseRequest[T]) CreateOrUpdate(obj client.Object, fn controllerutil.MutateFn) (result controllerutil.OperationResult, err error) {
+	ctx, end := r.trace("CreateOrUpdate", obj)
+	defer func() { end(err) }()
+
+	result, err = controllerutil.CreateOrUpdate(ctx, r.Client, obj, fn)
+	if err != nil {
+		// the object was found if it carries a resource version
+		action := "Update"
+		if obj.GetResourceVersion() == "" {
+			action = "Create"
+		}
+		r.objectErrorEvent(obj, action, err)
+		return result, err
+	}
+	switch result {
+	case controllerutil.OperationResultCreated:
+		r.objectEvent(obj, "Created")
+	case controllerutil.OperationResultUpdated:
+		r.objectEvent(obj, "Updated")
+	}
+	return result, err
 }
 
-func (r *BaseRequest[T]) UpdateStatus() error {
-	return r.Client.Status().Update(r.Context, r.Cr)
+func (r *BaseRequest[T]) Get(obj client.Object) (err error) {
+	ctx, end := r.trace("Get", obj)
+	defer func() { end(err) }()
+
+	return r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
 }
 
-func (r *BaseRequest[T]) Update(obj client.Object) error {
-	return r.Client.Update(r.Context, obj)
+func (r *BaseRequest[T]) UpdateStatus() (err error) {
+	ctx, end := r.trace("UpdateStatus", r.Cr)
+	defer func() { end(err) }()
+
+	return r.Client.Status().Update(ctx, r.Cr)
 }
 
-func (r *BaseRequest[T]) Patch(obj client.Object, fn controllerutil.MutateFn) error {
+func (r *BaseRequest[T]) Update(obj client.Object) (err error) {
+	ctx, end := r.trace("Update", obj)
+	defer func() { end(err) }()
+
+	if err := r.Client.Update(ctx, obj); err != nil {
+		r.objectErrorEvent(obj, "Update", err)
+		return err
+	}
+	if client.Object(r.Cr) != obj {
+		r.objectEvent(obj, "Updated")
+	}
+	return nil
+}
+
+func (r *BaseRequest[T]) Patch(obj client.Object, fn controllerutil.MutateFn) (err error) {
+	ctx, end := r.trace("Patch", obj)
+	defer func() { end(err) }()
+
 	before := obj.DeepCopyObject()
 	patch := client.MergeFrom(before.(client.Object))
 	if err := fn(); err != nil {
 		return err
 	}
 
-	return r.Client.Patch(r.Context, obj, patch)
+	return r.Client.Patch(ctx, obj, patch)
 }
 
 func (r *BaseRequest[T]) DeleteIfExists(objs ...client.Object) error {
 	for _, obj := range objs {
-		if err := client.IgnoreNotFound(r.Client.Delete(r.Context, obj)); err != nil {
+		ctx, end := r.trace("Delete", obj)
+		err := r.Client.Delete(ctx, obj)
+		if errors.IsNotFound(err) {
+			end(nil)
+			continue
+		}
+		end(err)
+		if err != nil {
+			r.objectErrorEvent(obj, "Delete", err)
 			return err
 		}
+		r.objectEvent(obj, "Deleted")
 	}
 	return nil
 }
 
-func (r *BaseRequest[T]) List(list client.ObjectList, ops ...client.ListOption) error {
-	return r.Client.List(r.Context, list, ops...)
+func (r *BaseRequest[T]) List(list client.ObjectList, ops ...client.ListOption) (err error) {
+	ctx, span := tracing.Start(r.Context, "List "+r.kindOf(list), r.Namespace(), r.Name())
+	defer func() { tracing.End(span, err) }()
+
+	return r.Client.List(ctx, list, ops...)
+}
+
+// Wraps a step of the request in a span about the CR. The API calls of the step are
+// traced as children of the step
+func (r *BaseRequest[T]) Traced(name string, fn func() error) func() error {
+	return func() error {
+		parent := r.Context
+		ctx, span := tracing.Start(parent, name, r.Namespace(), r.Name())
+		r.Context = ctx
+		defer func() { r.Context = parent }()
+
+		err := fn()
+		tracing.End(span, err)
+		return err
+	}
+}
+
+// Starts the span of an API call on the object, e.g. "Get ConfigMap"
+func (r *BaseRequest[T]) trace(action string, obj client.Object) (context.Context, func(error)) {
+	ctx, span := tracing.Start(r.Context, action+" "+r.kindOf(obj), r.Namespace(), r.Name(),
+		tracing.ObjectKindKey.String(r.kindOf(obj)),
+		tracing.ObjectNameKey.String(obj.GetName()),
+	)
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+func (r *BaseRequest[T]) kindOf(obj runtime.Object) string {
+	if r.Scheme != nil {
+		if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+			return gvk.Kind
+		}
+	}
+	return obj.GetObjectKind().GroupVersionKind().Kind
+}
+
+// Records an event on the CR
+func (r *BaseRequest[T]) Event(eventtype, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(r.Cr, eventtype, reason, message)
+	}
+}
+
+// Records a Normal event on the CR about a change of the object, e.g. "Created ConfigMap turbo-config"
+func (r *BaseRequest[T]) objectEvent(obj client.Object, action string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(r.Cr, corev1.EventTypeNormal, action, "%s %s %s", action, r.kindOf(obj), obj.GetName())
+}
+
+// Records a Warning event on the CR about a failed change of the object, e.g. "FailedCreate Failed to create
+// ConfigMap turbo-config: ...". Conflicts are left out, the reconcile is retried on them anyway, and so are
+// the objects deleted on purpose to be recreated, their deletion is recorded instead
+func (r *BaseRequest[T]) objectErrorEvent(obj client.Object, action string, err error) {
+	if r.Recorder == nil || errors.IsConflict(err) || err == constants.ErrRequeueOnDeletion {
+		return
+	}
+	r.Recorder.Eventf(r.Cr, corev1.EventTypeWarning, "Failed"+action, "Failed to %s %s %s: %s",
+		strings.ToLower(action), r.kindOf(obj), obj.GetName(), err)
 }
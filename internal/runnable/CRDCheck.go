@@ -19,13 +19,21 @@ package runnable
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
+	"sync"
+	"time"
 
+	"github.ibm.com/turbonomic/kubeturbo-deploy/config/crd"
 	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/crdschema"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -39,13 +47,32 @@ type CRDCheck struct {
 	CRDName      string
 	Recorder     record.EventRecorder
 	CRDCheckDone *chan interface{}
+	// Applies the CRD the operator is built with when the installed one is missing or outdated
+	InstallCRD bool
+	// Delays between the attempts of a failed check, DefaultCRDCheckBackoff if not set
+	Backoff *wait.Backoff
+
+	mu sync.Mutex
+	// The result of the last attempt, nil until the first attempt completes
+	result *error
 }
 
 var (
 	logger = ctrl.Log.WithName("Post-start-check")
+
+	// Retry a failed check after 5s, doubling the delay up to 5min
+	DefaultCRDCheckBackoff = wait.Backoff{
+		Duration: 5 * time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    math.MaxInt32,
+		Cap:      5 * time.Minute,
+	}
 )
 
 // Start is the method called after mgr.Start
+// The check is retried with backoff until it passes, the reconciles are blocked until then
+// and the state of the check is reported through Checker
 func (r *CRDCheck) Start(ctx context.Context) error {
 	logger.Info(fmt.Sprintf("Validation CRD %s...", r.CRDName))
 
@@ -54,22 +81,29 @@ func (r *CRDCheck) Start(ctx context.Context) error {
 		logger.Info("The operator is not running in the pod mode")
 	}
 
-	// Check if the crd exists in the target cluster
-	// Populate the error to cause the operator exit with an expectation
-	if err := r.IsCrdExists(ctx); err != nil {
-		if deployment != nil {
-			r.Recorder.Event(deployment, "Warning", "CRDIssue", err.Error())
-		}
-		return err
+	backoff := DefaultCRDCheckBackoff
+	if r.Backoff != nil {
+		backoff = *r.Backoff
 	}
+	for {
+		err := r.check(ctx)
+		r.setResult(err)
+		if err == nil {
+			break
+		}
 
-	// Check the existed crd satisfy the minimum requirements or not
-	// Exit the operator with a message to ask the client to update their existing CRD
-	if err := r.IsCrdUpToDate(ctx); err != nil {
+		// Tell the client to install or update their existing CRD
 		if deployment != nil {
 			r.Recorder.Event(deployment, "Warning", "CRDIssue", err.Error())
 		}
-		return err
+		delay := backoff.Step()
+		logger.Error(err, fmt.Sprintf("Validation CRD %s failed, retrying in %s", r.CRDName, delay.Round(time.Second)))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
 	}
 
 	logger.Info(fmt.Sprintf("Validation CRD %s passed", r.CRDName))
@@ -81,6 +115,45 @@ func (r *CRDCheck) Start(ctx context.Context) error {
 	return nil
 }
 
+// NeedLeaderElection is false for every replica to report its readiness
+func (r *CRDCheck) NeedLeaderElection() bool {
+	return false
+}
+
+// Checker reports whether the CRD check passed, to be registered as a readiness check
+func (r *CRDCheck) Checker(_ *http.Request) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.result == nil {
+		return fmt.Errorf("validation of CRD %s is in progress", r.CRDName)
+	}
+	return *r.result
+}
+
+func (r *CRDCheck) setResult(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result = &err
+}
+
+// Checks the crd exists and satisfies the minimum requirements,
+// applies the embedded crd first if it doesn't and the operator is allowed to
+func (r *CRDCheck) check(ctx context.Context) error {
+	err := r.IsCrdExists(ctx)
+	if err == nil {
+		err = r.IsCrdUpToDate(ctx)
+	}
+	if err == nil || !r.InstallCRD {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Applying CRD %s: %s", r.CRDName, err))
+	if err := r.ApplyCrd(ctx); err != nil {
+		return err
+	}
+	return r.IsCrdUpToDate(ctx)
+}
+
 // Get the deployment object for the current pod container
 func GetOperatorDeployment(ctx context.Context, c client.Client) *appsv1.Deployment {
 	operator_pod_name := getOsEnv("POD_NAME")
@@ -164,11 +237,11 @@ func (r *CRDCheck) IsCrdExists(ctx context.Context) error {
 	return nil
 }
 
-// Check if the crd is not in the old version
+// Check if the crd is not in the old version and holds every field the operator relies on
 func (r *CRDCheck) IsCrdUpToDate(ctx context.Context) error {
 	// Fetch the target as an CRD object
-	crd := &apiextensionsv1.CustomResourceDefinition{}
-	if err := r.Get(ctx, client.ObjectKey{Name: r.CRDName}, crd); err != nil {
+	installed := &apiextensionsv1.CustomResourceDefinition{}
+	if err := r.Get(ctx, client.ObjectKey{Name: r.CRDName}, installed); err != nil {
 		return err
 	}
 
@@ -180,7 +253,7 @@ func (r *CRDCheck) IsCrdUpToDate(ctx context.Context) error {
 		// factor to distinguish the older CRD verses the new one
 		// The following section is to verify if the existing Kubeturbo CRD satisfy the requirements.
 		foundAnnotation := false
-		for k := range crd.Annotations {
+		for k := range installed.Annotations {
 			if k == constants.ControlGenAnnotation {
 				foundAnnotation = true
 				break
@@ -189,12 +262,44 @@ func (r *CRDCheck) IsCrdUpToDate(ctx context.Context) error {
 		if !foundAnnotation {
 			return fmt.Errorf("since 8.14.3, kubeturbo operator has moved from helm operator to go based operator. Please refer to https://ibm.biz/KubeturboCRD to install and upgrade to the latest CRD")
 		}
+
+		// A CRD generated for an older release of the operator has the annotation too but
+		// may lack newer fields, which the API server would silently prune from the CRs.
+		// Compare the installed CRD with the one the operator is built with
+		expected, err := crdschema.Parse(crd.Kubeturbo)
+		if err != nil {
+			return err
+		}
+		if err := crdschema.Compatible(installed, expected); err != nil {
+			return fmt.Errorf("%w. Please refer to https://ibm.biz/KubeturboCRD to upgrade to the latest CRD", err)
+		}
 	}
 
 	logger.Info(fmt.Sprintf("CRD %s meets the minimum version requirement", r.CRDName))
 	return nil
 }
 
+// Creates or upgrades the crd to the one the operator is built with using server-side apply
+func (r *CRDCheck) ApplyCrd(ctx context.Context) error {
+	if r.CRDName != constants.KubeturboCRDName {
+		return fmt.Errorf("the operator doesn't ship the CRD %s", r.CRDName)
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(crd.Kubeturbo, &obj.Object); err != nil {
+		return fmt.Errorf("invalid CRD %s: %w", r.CRDName, err)
+	}
+	// The status is owned by the API server
+	delete(obj.Object, "status")
+
+	if err := r.Patch(ctx, obj, client.Apply, client.FieldOwner(constants.OperatorName), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply CRD %s: %w", r.CRDName, err)
+	}
+
+	logger.Info(fmt.Sprintf("CRD %s applied", r.CRDName))
+	return nil
+}
+
 // Get global variable defined in OS
 func getOsEnv(field string) *string {
 	val, found := os.LookupEnv(field)
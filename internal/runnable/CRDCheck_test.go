@@ -0,0 +1,86 @@
+package runnable_test
+
+import (
+	"context"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/config/crd"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/constants"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/crdschema"
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/runnable"
+)
+
+var _ = Describe("CRDCheck", func() {
+	var (
+		c     client.Client
+		check *runnable.CRDCheck
+		done  chan interface{}
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).Build()
+		done = make(chan interface{})
+		check = &runnable.CRDCheck{
+			Client:       c,
+			CRDName:      constants.KubeturboCRDName,
+			Recorder:     record.NewFakeRecorder(10),
+			CRDCheckDone: &done,
+			Backoff:      &wait.Backoff{Duration: 10 * time.Millisecond, Steps: math.MaxInt32},
+		}
+	})
+
+	start := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		go func() {
+			defer GinkgoRecover()
+			Expect(check.Start(ctx)).To(Succeed())
+		}()
+	}
+
+	embedded := func() *apiextensionsv1.CustomResourceDefinition {
+		kubeturboCRD, err := crdschema.Parse(crd.Kubeturbo)
+		Expect(err).NotTo(HaveOccurred())
+		return kubeturboCRD
+	}
+
+	It("is not ready before the first attempt", func() {
+		Expect(check.Checker(nil)).To(MatchError(ContainSubstring("in progress")))
+	})
+
+	It("retries until the CRD is installed", func() {
+		start()
+		Eventually(func() error { return check.Checker(nil) }).Should(MatchError(ContainSubstring("not found")))
+		Consistently(done, 50*time.Millisecond).ShouldNot(BeClosed())
+
+		Expect(c.Create(context.Background(), embedded())).To(Succeed())
+		Eventually(done).Should(BeClosed())
+		Expect(check.Checker(nil)).To(Succeed())
+	})
+
+	It("reports the fields an outdated CRD lacks", func() {
+		outdated := embedded()
+		schema, err := crdschema.VersionSchema(outdated, "v1")
+		Expect(err).NotTo(HaveOccurred())
+		spec := schema.Properties["spec"]
+		delete(spec.Properties, "discovery")
+		schema.Properties["spec"] = spec
+		Expect(c.Create(context.Background(), outdated)).To(Succeed())
+
+		start()
+		Eventually(func() error { return check.Checker(nil) }).Should(MatchError(ContainSubstring("spec.discovery")))
+		Consistently(done, 50*time.Millisecond).ShouldNot(BeClosed())
+	})
+})
@@ -0,0 +1,13 @@
+package runnable_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRunnable(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runnable Suite")
+}
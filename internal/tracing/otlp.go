@@ -0,0 +1,245 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exports spans with OTLP over HTTP using the JSON encoding, which every OTLP/HTTP receiver
+// accepts and which doesn't need the protobuf and gRPC stacks of the OTLP exporters. Only the
+// fields the operator records are encoded: no links, dropped counts, trace state or schema URLs
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+var _ sdktrace.SpanExporter = &OTLPExporter{}
+
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{endpoint: endpoint, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to export %d spans to %s: %s %s", len(spans), e.endpoint, resp.Status, msg)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// The JSON mapping of the OTLP ExportTraceServiceRequest, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+// Groups the spans by resource and instrumentation scope
+func encodeSpans(spans []sdktrace.ReadOnlySpan) otlpRequest {
+	req := otlpRequest{}
+	resourceIndex := map[attribute.Distinct]int{}
+	scopeIndex := map[attribute.Distinct]map[string]int{}
+	for _, span := range spans {
+		res := span.Resource()
+		key := res.Equivalent()
+		i, found := resourceIndex[key]
+		if !found {
+			i = len(req.ResourceSpans)
+			resourceIndex[key] = i
+			scopeIndex[key] = map[string]int{}
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{Attributes: encodeAttributes(res.Attributes())},
+			})
+		}
+		resourceSpans := &req.ResourceSpans[i]
+
+		scope := span.InstrumentationScope()
+		j, found := scopeIndex[key][scope.Name+"@"+scope.Version]
+		if !found {
+			j = len(resourceSpans.ScopeSpans)
+			scopeIndex[key][scope.Name+"@"+scope.Version] = j
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, otlpScopeSpans{
+				Scope: otlpScope{Name: scope.Name, Version: scope.Version},
+			})
+		}
+		resourceSpans.ScopeSpans[j].Spans = append(resourceSpans.ScopeSpans[j].Spans, encodeSpan(span))
+	}
+	return req
+}
+
+func encodeSpan(span sdktrace.ReadOnlySpan) otlpSpan {
+	sc := span.SpanContext()
+	traceID, spanID := sc.TraceID(), sc.SpanID()
+	s := otlpSpan{
+		TraceID:           hex.EncodeToString(traceID[:]),
+		SpanID:            hex.EncodeToString(spanID[:]),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: unixNano(span.StartTime()),
+		EndTimeUnixNano:   unixNano(span.EndTime()),
+		Attributes:        encodeAttributes(span.Attributes()),
+	}
+	if parent := span.Parent(); parent.IsValid() {
+		parentID := parent.SpanID()
+		s.ParentSpanID = hex.EncodeToString(parentID[:])
+	}
+	for _, event := range span.Events() {
+		s.Events = append(s.Events, otlpEvent{
+			TimeUnixNano: unixNano(event.Time),
+			Name:         event.Name,
+			Attributes:   encodeAttributes(event.Attributes),
+		})
+	}
+	// the codes of OpenTelemetry and OTLP differ
+	switch span.Status().Code {
+	case codes.Ok:
+		s.Status = otlpStatus{Code: 1}
+	case codes.Error:
+		s.Status = otlpStatus{Code: 2, Message: span.Status().Description}
+	}
+	return s
+}
+
+func encodeAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	var kvs []otlpKeyValue
+	for _, attr := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: string(attr.Key), Value: encodeValue(attr.Value)})
+	}
+	return kvs
+}
+
+func encodeValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		values := []otlpAnyValue{}
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, encodeValue(attribute.BoolValue(b)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := []otlpAnyValue{}
+		for _, i := range v.AsInt64Slice() {
+			values = append(values, encodeValue(attribute.Int64Value(i)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := []otlpAnyValue{}
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, encodeValue(attribute.Float64Value(f)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := []otlpAnyValue{}
+		for _, s := range v.AsStringSlice() {
+			values = append(values, encodeValue(attribute.StringValue(s)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		s := v.Emit()
+		return otlpAnyValue{StringValue: &s}
+	}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,81 @@
+// Package tracing traces the reconcile cycles of the operator with OpenTelemetry.
+// Spans go nowhere unless Setup is given an OTLP endpoint
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.ibm.com/turbonomic/kubeturbo-deploy"
+
+	// Attributes of every span about a Kubeturbo CR
+	NamespaceKey = attribute.Key("kubeturbo.namespace")
+	NameKey      = attribute.Key("kubeturbo.name")
+	// Attributes of the spans of API calls
+	ObjectKindKey = attribute.Key("k8s.object.kind")
+	ObjectNameKey = attribute.Key("k8s.object.name")
+
+	// The standard OpenTelemetry variables, the traces endpoint is used as is
+	// while the path of the traces is appended to the base endpoint
+	tracesEndpointEnvVar = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	endpointEnvVar       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	tracesPath           = "/v1/traces"
+)
+
+// The tracer of the operator, from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Starts a span about the Kubeturbo CR
+func Start(ctx context.Context, name, namespace, crName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{NamespaceKey.String(namespace), NameKey.String(crName)}, attrs...)
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Ends the span, marking it as failed if there's an error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// The OTLP traces endpoint configured through the standard environment variables, empty if none
+func EndpointFromEnv() string {
+	if endpoint := os.Getenv(tracesEndpointEnvVar); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv(endpointEnvVar); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + tracesPath
+	}
+	return ""
+}
+
+// Installs a global tracer provider exporting the spans to the OTLP traces endpoint, e.g.
+// http://otel-collector:4318/v1/traces. Nothing is installed if the endpoint is empty.
+// The returned function flushes the pending spans
+func Setup(endpoint, serviceName string) func(context.Context) error {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(NewOTLPExporter(endpoint)),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown
+}
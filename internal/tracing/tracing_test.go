@@ -0,0 +1,235 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/tracing"
+)
+
+var _ = Describe("Tracing", func() {
+	Describe("EndpointFromEnv", func() {
+		It("prefers the traces endpoint", func() {
+			GinkgoT().Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+			GinkgoT().Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://traces:4318/custom")
+			Expect(tracing.EndpointFromEnv()).To(Equal("http://traces:4318/custom"))
+		})
+
+		It("appends the traces path to the base endpoint", func() {
+			GinkgoT().Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+			GinkgoT().Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+			Expect(tracing.EndpointFromEnv()).To(Equal("http://collector:4318/v1/traces"))
+		})
+	})
+
+	Describe("OTLPExporter", func() {
+		var (
+			requests chan map[string]interface{}
+			status   int
+			server   *httptest.Server
+		)
+
+		BeforeEach(func() {
+			requests = make(chan map[string]interface{}, 1)
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				body, err := io.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				req := map[string]interface{}{}
+				Expect(json.Unmarshal(body, &req)).To(Succeed())
+				requests <- req
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		// records a parent and a failed child span
+		recordSpans := func() []sdktrace.ReadOnlySpan {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(
+				sdktrace.WithSpanProcessor(recorder),
+				sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "test"))),
+			)
+			ctx, parent := provider.Tracer("test").Start(context.Background(), "Reconcile")
+			_, child := provider.Tracer("test").Start(ctx, "Get ConfigMap")
+			child.SetAttributes(attribute.Int("retries", 2))
+			tracing.End(child, errors.New("boom"))
+			parent.End()
+			return recorder.Ended()
+		}
+
+		It("posts the spans as OTLP JSON", func() {
+			spans := recordSpans()
+			Expect(tracing.NewOTLPExporter(server.URL).ExportSpans(context.Background(), spans)).To(Succeed())
+
+			var req map[string]interface{}
+			Eventually(requests).Should(Receive(&req))
+			resourceSpans := req["resourceSpans"].([]interface{})
+			Expect(resourceSpans).To(HaveLen(1))
+			Expect(resourceSpans[0]).To(HaveKeyWithValue("resource", HaveKeyWithValue("attributes", ContainElement(
+				map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "test"}},
+			))))
+			scopeSpans := resourceSpans[0].(map[string]interface{})["scopeSpans"].([]interface{})
+			Expect(scopeSpans).To(HaveLen(1))
+			Expect(scopeSpans[0]).To(HaveKeyWithValue("scope", HaveKeyWithValue("name", "test")))
+
+			exported := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+			Expect(exported).To(HaveLen(2))
+			child, parent := exported[0].(map[string]interface{}), exported[1].(map[string]interface{})
+			Expect(child["name"]).To(Equal("Get ConfigMap"))
+			Expect(child["traceId"]).To(Equal(spans[0].SpanContext().TraceID().String()))
+			Expect(child["spanId"]).To(Equal(spans[0].SpanContext().SpanID().String()))
+			Expect(child["parentSpanId"]).To(Equal(parent["spanId"]))
+			Expect(child["status"]).To(Equal(map[string]interface{}{"code": 2.0, "message": "boom"}))
+			Expect(child["attributes"]).To(ContainElement(
+				map[string]interface{}{"key": "retries", "value": map[string]interface{}{"intValue": "2"}},
+			))
+			Expect(child["events"]).To(ContainElement(HaveKeyWithValue("name", "exception")))
+			Expect(child["startTimeUnixNano"]).To(MatchRegexp(`^\d+$`))
+
+			Expect(parent["name"]).To(Equal("Reconcile"))
+			Expect(parent).NotTo(HaveKey("parentSpanId"))
+			Expect(parent["status"]).To(BeEmpty())
+		})
+
+		// exports the span and returns the JSON the collector received
+		export := func(span tracetest.SpanStub) string {
+			Expect(tracing.NewOTLPExporter(server.URL).ExportSpans(context.Background(),
+				tracetest.SpanStubs{span}.Snapshots())).To(Succeed())
+			var req map[string]interface{}
+			Eventually(requests).Should(Receive(&req))
+			out, err := json.Marshal(req)
+			Expect(err).NotTo(HaveOccurred())
+			return string(out)
+		}
+
+		// the span of the JSON mapping of the OTLP ExportTraceServiceRequest
+		exportedSpan := func(span tracetest.SpanStub) map[string]interface{} {
+			req := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(export(span)), &req)).To(Succeed())
+			resourceSpans := req["resourceSpans"].([]interface{})[0].(map[string]interface{})
+			scopeSpans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})
+			return scopeSpans["spans"].([]interface{})[0].(map[string]interface{})
+		}
+
+		It("follows the OTLP JSON mapping of the trace proto", func() {
+			traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+			start := time.Unix(1700000000, 123456789)
+			span := tracetest.SpanStub{
+				Name: "Get ConfigMap",
+				SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: traceID, SpanID: trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, TraceFlags: trace.FlagsSampled,
+				}),
+				Parent: trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: traceID, SpanID: trace.SpanID{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18}, TraceFlags: trace.FlagsSampled,
+				}),
+				SpanKind:  trace.SpanKindClient,
+				StartTime: start,
+				EndTime:   start.Add(1500 * time.Millisecond),
+				Attributes: []attribute.KeyValue{
+					attribute.String("k8s.object.kind", "ConfigMap"),
+					attribute.Bool("cached", true),
+					attribute.Int64("retries", -2),
+					attribute.Float64("ratio", 0.5),
+					attribute.StringSlice("names", []string{"a", "b"}),
+					attribute.Int64Slice("sizes", []int64{1, 9007199254740993}),
+					attribute.BoolSlice("flags", []bool{false}),
+					attribute.Float64Slice("weights", []float64{1.5}),
+				},
+				Events: []sdktrace.Event{{
+					Name:       "retry",
+					Time:       start.Add(time.Second),
+					Attributes: []attribute.KeyValue{attribute.Int("attempt", 1)},
+				}},
+				Status:                 sdktrace.Status{Code: codes.Error, Description: "boom"},
+				Resource:               resource.NewSchemaless(attribute.String("service.name", "kubeturbo-operator")),
+				InstrumentationLibrary: instrumentation.Scope{Name: "kubeturbo-operator", Version: "v1"},
+			}
+
+			Expect(export(span)).To(MatchJSON(`{
+				"resourceSpans": [{
+					"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "kubeturbo-operator"}}]},
+					"scopeSpans": [{
+						"scope": {"name": "kubeturbo-operator", "version": "v1"},
+						"spans": [{
+							"traceId": "0102030405060708090a0b0c0d0e0f10",
+							"spanId": "0102030405060708",
+							"parentSpanId": "1112131415161718",
+							"name": "Get ConfigMap",
+							"kind": 3,
+							"startTimeUnixNano": "1700000000123456789",
+							"endTimeUnixNano": "1700000001623456789",
+							"attributes": [
+								{"key": "k8s.object.kind", "value": {"stringValue": "ConfigMap"}},
+								{"key": "cached", "value": {"boolValue": true}},
+								{"key": "retries", "value": {"intValue": "-2"}},
+								{"key": "ratio", "value": {"doubleValue": 0.5}},
+								{"key": "names", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"stringValue": "b"}]}}},
+								{"key": "sizes", "value": {"arrayValue": {"values": [{"intValue": "1"}, {"intValue": "9007199254740993"}]}}},
+								{"key": "flags", "value": {"arrayValue": {"values": [{"boolValue": false}]}}},
+								{"key": "weights", "value": {"arrayValue": {"values": [{"doubleValue": 1.5}]}}}
+							],
+							"events": [{
+								"timeUnixNano": "1700000001123456789",
+								"name": "retry",
+								"attributes": [{"key": "attempt", "value": {"intValue": "1"}}]
+							}],
+							"status": {"code": 2, "message": "boom"}
+						}]
+					}]
+				}]
+			}`))
+		})
+
+		// the SpanKind enum of the trace proto
+		DescribeTable("maps the span kind",
+			func(kind trace.SpanKind, expected float64) {
+				Expect(exportedSpan(tracetest.SpanStub{Name: "span", SpanKind: kind})["kind"]).To(Equal(expected))
+			},
+			Entry("unspecified", trace.SpanKindUnspecified, 0.0),
+			Entry("internal", trace.SpanKindInternal, 1.0),
+			Entry("server", trace.SpanKindServer, 2.0),
+			Entry("client", trace.SpanKindClient, 3.0),
+			Entry("producer", trace.SpanKindProducer, 4.0),
+			Entry("consumer", trace.SpanKindConsumer, 5.0),
+		)
+
+		// the StatusCode enum of the trace proto, whose default is left out like any proto3 default
+		DescribeTable("maps the status code",
+			func(status sdktrace.Status, expected map[string]interface{}) {
+				Expect(exportedSpan(tracetest.SpanStub{Name: "span", Status: status})["status"]).To(Equal(expected))
+			},
+			Entry("unset", sdktrace.Status{Code: codes.Unset}, map[string]interface{}{}),
+			Entry("ok", sdktrace.Status{Code: codes.Ok}, map[string]interface{}{"code": 1.0}),
+			Entry("error", sdktrace.Status{Code: codes.Error, Description: "boom"}, map[string]interface{}{"code": 2.0, "message": "boom"}),
+		)
+
+		It("leaves out the parent of a root span", func() {
+			Expect(exportedSpan(tracetest.SpanStub{Name: "span"})).NotTo(HaveKey("parentSpanId"))
+		})
+
+		It("fails when the collector rejects the spans", func() {
+			status = http.StatusBadRequest
+			Expect(tracing.NewOTLPExporter(server.URL).ExportSpans(context.Background(), recordSpans())).
+				To(MatchError(ContainSubstring("400 Bad Request")))
+		})
+	})
+})
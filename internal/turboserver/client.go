@@ -0,0 +1,208 @@
+package turboserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Version endpoint of the Turbo Server that doesn't require a login
+	VersionPath = "/api/v3/admin/versions"
+	// Login endpoint of the Turbo Server, which takes the credentials as a form
+	LoginPath = "/api/v3/login"
+
+	DefaultTimeout = 5 * time.Second
+)
+
+// Reasons why the Turbo Server could not be reached
+const (
+	ReasonReachable          = "Reachable"
+	ReasonInvalidConfig      = "InvalidConfiguration"
+	ReasonProxyError         = "ProxyError"
+	ReasonTLSError           = "TLSError"
+	ReasonUnreachable        = "Unreachable"
+	ReasonUnexpectedResponse = "UnexpectedResponse"
+	ReasonInvalidCredentials = "InvalidCredentials"
+)
+
+type Options struct {
+	// Base URL of the Turbo Server, e.g. https://turbo.example.com
+	ServerURL string
+	// Optional proxy URL, which may include the proxy credentials
+	ProxyURL string
+	// Additional PEM encoded CAs trusted on top of the system CAs
+	TrustedCAs []byte
+	// Timeout of a single request, DefaultTimeout if not set
+	Timeout time.Duration
+}
+
+// Client talks to the unauthenticated endpoints of the Turbo Server and checks credentials
+type Client struct {
+	serverURL  *url.URL
+	proxied    bool
+	httpClient *http.Client
+}
+
+// Version information published by the Turbo Server
+type VersionInfo struct {
+	// Version of the Turbo Server, e.g. 8.14.3
+	Version string `json:"version"`
+	// Full description of the version including the build
+	VersionInfo string `json:"versionInfo,omitempty"`
+}
+
+// Error carries the reason the Turbo Server could not be reached
+type Error struct {
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Returns the reason of a failed call, or ReasonUnreachable for errors not produced by this package
+func ReasonOf(err error) string {
+	var turboErr *Error
+	if errors.As(err, &turboErr) {
+		return turboErr.Reason
+	}
+	return ReasonUnreachable
+}
+
+func NewClient(opts Options) (*Client, error) {
+	serverURL, err := url.Parse(opts.ServerURL)
+	if err != nil || serverURL.Host == "" {
+		return nil, &Error{Reason: ReasonInvalidConfig, Err: fmt.Errorf("invalid Turbo Server URL %q", opts.ServerURL)}
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(opts.TrustedCAs) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(opts.TrustedCAs) {
+			return nil, &Error{Reason: ReasonInvalidConfig, Err: fmt.Errorf("the trusted CA bundle contains no PEM encoded certificate")}
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+	}
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			// don't leak the proxy credentials in the error
+			return nil, &Error{Reason: ReasonInvalidConfig, Err: fmt.Errorf("invalid proxy URL")}
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Client{
+		serverURL:  serverURL,
+		proxied:    opts.ProxyURL != "",
+		httpClient: &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+// Queries the version of the Turbo Server. A returned error is always of type *Error
+func (c *Client) GetVersion(ctx context.Context) (*VersionInfo, error) {
+	endpoint := c.serverURL.JoinPath(VersionPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, &Error{Reason: ReasonInvalidConfig, Err: err}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.classify(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return nil, &Error{Reason: ReasonProxyError, Err: fmt.Errorf("the proxy rejected the credentials: %s", resp.Status)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &Error{Reason: ReasonUnexpectedResponse, Err: fmt.Errorf("%s responded with %s", endpoint.Redacted(), resp.Status)}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, c.classify(err)
+	}
+	info := &VersionInfo{}
+	if err := json.Unmarshal(body, info); err != nil || info.Version == "" {
+		return nil, &Error{Reason: ReasonUnexpectedResponse, Err: fmt.Errorf("%s didn't respond with a version", endpoint.Redacted())}
+	}
+	return info, nil
+}
+
+// Logs in to the Turbo Server to check the credentials. A returned error is always of type *Error
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	endpoint := c.serverURL.JoinPath(LoginPath)
+	form := url.Values{"username": {username}, "password": {password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return &Error{Reason: ReasonInvalidConfig, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return c.classify(err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &Error{Reason: ReasonInvalidCredentials, Err: fmt.Errorf("the Turbo server rejected the credentials of user %s: %s", username, resp.Status)}
+	case http.StatusProxyAuthRequired:
+		return &Error{Reason: ReasonProxyError, Err: fmt.Errorf("the proxy rejected the credentials: %s", resp.Status)}
+	default:
+		return &Error{Reason: ReasonUnexpectedResponse, Err: fmt.Errorf("%s responded with %s", endpoint.Redacted(), resp.Status)}
+	}
+}
+
+func (c *Client) classify(err error) error {
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &recordHeaderErr) {
+		return &Error{Reason: ReasonTLSError, Err: err}
+	}
+
+	if c.proxied {
+		var opErr *net.OpError
+		// errors on the way to the proxy or answers of the proxy to CONNECT
+		if (errors.As(err, &opErr) && opErr.Op == "proxyconnect") || strings.Contains(strings.ToLower(err.Error()), "proxy") {
+			return &Error{Reason: ReasonProxyError, Err: err}
+		}
+	}
+	return &Error{Reason: ReasonUnreachable, Err: err}
+}
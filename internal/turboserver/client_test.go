@@ -0,0 +1,143 @@
+package turboserver_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/turboserver"
+)
+
+// Stand-in for the Turbo Server serving the version endpoint and accepting the administrator login
+func newTurboServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(turboserver.VersionPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"versionInfo":"Turbonomic Operations Manager 8.14.3 (Build \"20240801\")","version":"8.14.3"}`))
+	})
+	mux.HandleFunc(turboserver.LoginPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.PostFormValue("username") != "administrator" || r.PostFormValue("password") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+func serverCA(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+var _ = Describe("Client", func() {
+	ctx := context.Background()
+
+	When("The server is trusted", func() {
+		It("Returns the version of the server", func() {
+			server := newTurboServer()
+			defer server.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL, TrustedCAs: serverCA(server)})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := c.GetVersion(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Version).To(Equal("8.14.3"))
+		})
+	})
+
+	When("Logging in", func() {
+		It("Accepts valid credentials and reports invalid ones", func() {
+			server := newTurboServer()
+			defer server.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL, TrustedCAs: serverCA(server)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(c.Login(ctx, "administrator", "secret")).To(Succeed())
+			err = c.Login(ctx, "administrator", "wrong")
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonInvalidCredentials))
+			Expect(err.Error()).NotTo(ContainSubstring("wrong"))
+		})
+	})
+
+	When("The server certificate isn't signed by a trusted CA", func() {
+		It("Reports a TLS error", func() {
+			server := newTurboServer()
+			defer server.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.GetVersion(ctx)
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonTLSError))
+		})
+	})
+
+	When("The server doesn't serve the version endpoint", func() {
+		It("Reports an unexpected response", func() {
+			server := httptest.NewTLSServer(http.NotFoundHandler())
+			defer server.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL, TrustedCAs: serverCA(server)})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.GetVersion(ctx)
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonUnexpectedResponse))
+		})
+	})
+
+	When("The server is down", func() {
+		It("Reports the server as unreachable", func() {
+			server := newTurboServer()
+			server.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.GetVersion(ctx)
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonUnreachable))
+		})
+	})
+
+	When("The proxy is down", func() {
+		It("Reports a proxy error", func() {
+			server := newTurboServer()
+			defer server.Close()
+			proxy := httptest.NewServer(http.NotFoundHandler())
+			proxy.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL, ProxyURL: proxy.URL, TrustedCAs: serverCA(server)})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.GetVersion(ctx)
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonProxyError))
+		})
+	})
+
+	When("The proxy requires authentication", func() {
+		It("Reports a proxy error", func() {
+			server := newTurboServer()
+			defer server.Close()
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+			}))
+			defer proxy.Close()
+
+			c, err := turboserver.NewClient(turboserver.Options{ServerURL: server.URL, ProxyURL: proxy.URL, TrustedCAs: serverCA(server)})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.GetVersion(ctx)
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonProxyError))
+		})
+	})
+
+	When("The server URL is invalid", func() {
+		It("Fails to create the client", func() {
+			_, err := turboserver.NewClient(turboserver.Options{ServerURL: "Turbo_server_URL"})
+			Expect(turboserver.ReasonOf(err)).To(Equal(turboserver.ReasonInvalidConfig))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package turboserver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTurboserver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Turboserver Suite")
+}
@@ -0,0 +1,50 @@
+package turboserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kubeturbo is supported with a Turbo Server of the same major version that is
+// at most this many minor versions apart
+const MaxMinorVersionSkew = 1
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Parses versions such as 8.14.3, v8.14 or 8.14.3-SNAPSHOT
+func ParseVersion(version string) (Version, error) {
+	v := Version{}
+	core, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), "-")
+	parts := strings.Split(core, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("%q is not a major.minor[.patch] version", version)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%q is not a major.minor[.patch] version", version)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Reports if the kubeturbo version is supported with the Turbo Server version
+func WithinSupportedSkew(kubeturbo, server Version) bool {
+	if kubeturbo.Major != server.Major {
+		return false
+	}
+	skew := kubeturbo.Minor - server.Minor
+	return skew <= MaxMinorVersionSkew && skew >= -MaxMinorVersionSkew
+}
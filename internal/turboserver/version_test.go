@@ -0,0 +1,33 @@
+package turboserver_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.ibm.com/turbonomic/kubeturbo-deploy/internal/turboserver"
+)
+
+var _ = Describe("Version", func() {
+	Describe("ParseVersion", func() {
+		It("Parses release, snapshot and short versions", func() {
+			Expect(turboserver.ParseVersion("8.14.3")).To(Equal(turboserver.Version{Major: 8, Minor: 14, Patch: 3}))
+			Expect(turboserver.ParseVersion("8.15.4-SNAPSHOT")).To(Equal(turboserver.Version{Major: 8, Minor: 15, Patch: 4}))
+			Expect(turboserver.ParseVersion("v8.14")).To(Equal(turboserver.Version{Major: 8, Minor: 14}))
+		})
+
+		It("Rejects tags that aren't versions", func() {
+			for _, tag := range []string{"latest", "8", "8.x.1", "8.14.3.1", ""} {
+				_, err := turboserver.ParseVersion(tag)
+				Expect(err).To(HaveOccurred(), tag)
+			}
+		})
+	})
+
+	Describe("WithinSupportedSkew", func() {
+		It("Allows adjacent minor versions of the same major version", func() {
+			Expect(turboserver.WithinSupportedSkew(turboserver.Version{Major: 8, Minor: 14}, turboserver.Version{Major: 8, Minor: 15})).To(BeTrue())
+			Expect(turboserver.WithinSupportedSkew(turboserver.Version{Major: 8, Minor: 14}, turboserver.Version{Major: 8, Minor: 16})).To(BeFalse())
+			Expect(turboserver.WithinSupportedSkew(turboserver.Version{Major: 8, Minor: 1}, turboserver.Version{Major: 9, Minor: 1})).To(BeFalse())
+		})
+	})
+})